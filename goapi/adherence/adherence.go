@@ -0,0 +1,289 @@
+// Package adherence compares the planned days of scheduled plans with the activities that were logged.
+//
+// Activities are paired with planned days by date, in the time zone of the athlete. An activity that is linked
+// to a plan day counts for that day, whatever date it was run on. Other activities count for a planned day on
+// the same date, unless they are linked to a workout that is not planned for that day. Activities that are not
+// paired with a planned day are extra.
+//
+// The compliance of a planned day is how much of the planned duration, distance and load the activities
+// covered, averaged over the measures both sides have. Each measure is capped at 1, so running longer than
+// planned does not make up for running shorter on another day. The load of an activity is its session RPE
+// scaled to the intensity coefficients: minutes times RPE / 10, so an hour at RPE 10 scores 60 like an hour of
+// intervals.
+package adherence
+
+import (
+	"goapi/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Status is the outcome of a day.
+type Status string
+
+const (
+	// Done is a planned day where the activities covered at least DoneThreshold of the plan.
+	Done Status = "done"
+	// Partial is a planned day with activities that covered less than DoneThreshold of the plan.
+	Partial Status = "partial"
+	// Missed is a planned day without any activities.
+	Missed Status = "missed"
+	// Extra is a day with activities that were not planned, including activities on rest days.
+	Extra Status = "extra"
+)
+
+// DoneThreshold is the compliance from which a planned day counts as done.
+const DoneThreshold = 0.8
+
+const daysPerWeek = 7
+
+// Planned is a planned day with the totals of its workouts. Totals that can not be estimated are 0 and are left
+// out of the compliance.
+type Planned struct {
+	// Date is the calendar date of the day, as midnight UTC.
+	Date       time.Time
+	DayId      string
+	WorkoutIds []string
+	Seconds    float64
+	Meters     float64
+	Load       float64
+}
+
+// IsRest tells whether no workouts are planned for the day.
+func (p Planned) IsRest() bool {
+	return len(p.WorkoutIds) == 0
+}
+
+// Day is the outcome of a planned day, or of the extra activities of a date.
+type Day struct {
+	Date time.Time
+	// Planned is nil for extra activities on dates without a planned day.
+	Planned    *Planned
+	Activities []models.Activity
+	Status     Status
+	// Compliance is between 0 and 1, and 0 for extra days.
+	Compliance float64
+}
+
+// Seconds is the total duration of the activities of the day.
+func (d Day) Seconds() float64 {
+	total := 0.0
+	for _, activity := range d.Activities {
+		total += float64(activity.Duration)
+	}
+	return total
+}
+
+// Meters is the total distance of the activities of the day.
+func (d Day) Meters() float64 {
+	total := 0.0
+	for _, activity := range d.Activities {
+		total += float64(activity.Distance)
+	}
+	return total
+}
+
+// Load is the total load of the activities of the day. The second return value is false when any of the
+// activities has no RPE.
+func (d Day) Load() (float64, bool) {
+	total := 0.0
+	for _, activity := range d.Activities {
+		load, ok := ActivityLoad(activity)
+		if !ok {
+			return 0, false
+		}
+		total += load
+	}
+	return total, len(d.Activities) > 0
+}
+
+// ActivityLoad is the session RPE load of an activity. The second return value is false when the activity has
+// no RPE.
+func ActivityLoad(activity models.Activity) (float64, bool) {
+	if activity.Rpe <= 0 {
+		return 0, false
+	}
+	return float64(activity.Duration) / 60 * float64(activity.Rpe) / 10, true
+}
+
+// Match pairs the planned days with the activities and judges every day. The start times of the activities are
+// converted to dates in the location. Planned days after today are left out, and so is today while nothing is
+// logged for it yet. Rest days only show up when activities were logged on them. The days are in date order,
+// with the planned days before the extra days of the same date.
+func Match(planned []Planned, activities []models.Activity, location *time.Location, today time.Time) []Day {
+	today = date(today)
+	byDay := map[string]int{}
+	byDate := map[time.Time][]int{}
+	days := make([]Day, 0, len(planned))
+	for _, plannedDay := range planned {
+		if plannedDay.Date.After(today) {
+			continue
+		}
+		plannedDay := plannedDay
+		days = append(days, Day{Date: plannedDay.Date, Planned: &plannedDay})
+		byDay[plannedDay.DayId] = len(days) - 1
+		byDate[plannedDay.Date] = append(byDate[plannedDay.Date], len(days)-1)
+	}
+
+	extras := map[time.Time][]models.Activity{}
+	for _, activity := range activities {
+		activityDate := date(activity.StartTime.In(location))
+		if i, ok := byDay[activity.DayId]; ok && activity.DayId != "" {
+			days[i].Activities = append(days[i].Activities, activity)
+			continue
+		}
+		paired := false
+		for _, i := range byDate[activityDate] {
+			if activity.DayId == "" && plansWorkout(*days[i].Planned, activity.WorkoutId) {
+				days[i].Activities = append(days[i].Activities, activity)
+				paired = true
+				break
+			}
+		}
+		if !paired && !activityDate.After(today) {
+			extras[activityDate] = append(extras[activityDate], activity)
+		}
+	}
+
+	judged := make([]Day, 0, len(days)+len(extras))
+	for _, day := range days {
+		switch {
+		case day.Planned.IsRest() && len(day.Activities) == 0:
+			continue
+		case day.Planned.IsRest():
+			day.Status = Extra
+		case len(day.Activities) == 0 && day.Date.Equal(today):
+			continue
+		case len(day.Activities) == 0:
+			day.Status = Missed
+		default:
+			day.Compliance = compliance(day)
+			day.Status = Partial
+			if day.Compliance >= DoneThreshold {
+				day.Status = Done
+			}
+		}
+		judged = append(judged, day)
+	}
+	for extraDate, dayActivities := range extras {
+		judged = append(judged, Day{Date: extraDate, Activities: dayActivities, Status: Extra})
+	}
+
+	sort.SliceStable(judged, func(i, j int) bool {
+		if !judged[i].Date.Equal(judged[j].Date) {
+			return judged[i].Date.Before(judged[j].Date)
+		}
+		return judged[i].Planned != nil && judged[j].Planned == nil
+	})
+	return judged
+}
+
+// Summary counts the outcomes of a number of days.
+type Summary struct {
+	Planned int
+	Done    int
+	Partial int
+	Missed  int
+	Extra   int
+	// Compliance is the sum of the compliance of the planned days.
+	Compliance float64
+}
+
+// Summarize counts the outcomes of the days.
+func Summarize(days []Day) Summary {
+	summary := Summary{}
+	for _, day := range days {
+		switch day.Status {
+		case Done:
+			summary.Done++
+		case Partial:
+			summary.Partial++
+		case Missed:
+			summary.Missed++
+		case Extra:
+			summary.Extra++
+			continue
+		}
+		summary.Planned++
+		summary.Compliance += day.Compliance
+	}
+	return summary
+}
+
+// Percentage is the average compliance of the planned days, from 0 to 100. The second return value is false
+// when no planned days are due yet.
+func (s Summary) Percentage() (float64, bool) {
+	if s.Planned == 0 {
+		return 0, false
+	}
+	return s.Compliance / float64(s.Planned) * 100, true
+}
+
+// Week is the summary of seven consecutive days.
+type Week struct {
+	Start   time.Time
+	Summary Summary
+}
+
+// Weeks summarizes the days in weeks of seven days from the start date. Days before the start are left out,
+// and weeks without days in between are included with an empty summary.
+func Weeks(days []Day, start time.Time) []Week {
+	start = date(start)
+	weeks := []Week{}
+	grouped := map[int][]Day{}
+	for _, day := range days {
+		if day.Date.Before(start) {
+			continue
+		}
+		index := int(day.Date.Sub(start).Hours()/24) / daysPerWeek
+		grouped[index] = append(grouped[index], day)
+		for len(weeks) <= index {
+			weeks = append(weeks, Week{Start: start.AddDate(0, 0, len(weeks)*daysPerWeek)})
+		}
+	}
+	for index, weekDays := range grouped {
+		weeks[index].Summary = Summarize(weekDays)
+	}
+	return weeks
+}
+
+// plansWorkout tells whether an activity linked to the workout fits the planned day. Activities that are not
+// linked to a workout fit any day.
+func plansWorkout(planned Planned, workoutId string) bool {
+	if workoutId == "" {
+		return true
+	}
+	for _, id := range planned.WorkoutIds {
+		if id == workoutId {
+			return true
+		}
+	}
+	return false
+}
+
+func compliance(day Day) float64 {
+	ratios := []float64{}
+	if actual := day.Seconds(); day.Planned.Seconds > 0 && actual > 0 {
+		ratios = append(ratios, actual/day.Planned.Seconds)
+	}
+	if actual := day.Meters(); day.Planned.Meters > 0 && actual > 0 {
+		ratios = append(ratios, actual/day.Planned.Meters)
+	}
+	if actual, ok := day.Load(); day.Planned.Load > 0 && ok {
+		ratios = append(ratios, actual/day.Planned.Load)
+	}
+	if len(ratios) == 0 {
+		return 1
+	}
+
+	total := 0.0
+	for _, ratio := range ratios {
+		total += math.Min(ratio, 1)
+	}
+	return total / float64(len(ratios))
+}
+
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
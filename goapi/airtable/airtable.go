@@ -5,14 +5,19 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 )
 
 const (
-	baseUrl = "https://api.airtable.com/v0/appKpRGYhVdY3IspT/"
+	// maxAttempts is the number of times a rate limited or failed request is sent before giving up.
+	maxAttempts    = 5
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 30 * time.Second
 )
 
 type Table string
@@ -28,8 +33,23 @@ const (
 	Record           Table = "Record"
 )
 
+// Tables lists all the tables of the base.
+var Tables = []Table{Intensity, Workout, WorkoutIntensity, Week, Day, Plan, Profile, Record}
+
+// ParseTable finds a table by name, ignoring case. The second return value is false for unknown tables.
+func ParseTable(name string) (Table, bool) {
+	for _, table := range Tables {
+		if strings.EqualFold(string(table), name) {
+			return table, true
+		}
+	}
+	return "", false
+}
+
 type AirtableResult struct {
 	Records []AirtableRecord `json:"records"`
+	// Offset is set when there are more pages, and is passed on to fetch the next page.
+	Offset string `json:"offset,omitempty"`
 }
 
 type AirtableRecord struct {
@@ -37,11 +57,15 @@ type AirtableRecord struct {
 	Fields json.RawMessage `json:"fields"`
 }
 
+// Client reads records from Airtable. The list calls follow the pages of the result until all records, or the
+// maximum number of records, are read.
 type Client interface {
-	GetAll(ctx context.Context, table Table, mapper airtableResultMapper) error
+	GetAll(ctx context.Context, table Table, mapper airtableResultMapper, options ...ListOption) error
 	Get(ctx context.Context, table Table, id string, mapper airtableRecordMapper) error
-	GetByParentId(ctx context.Context, table Table, parentTable Table, parentId string, result airtableResultMapper) error
-	GetByIds(ctx context.Context, table Table, ids []string, result airtableResultMapper) error
+	GetByParentId(ctx context.Context, table Table, parentTable Table, parentId string, result airtableResultMapper, options ...ListOption) error
+	GetByIds(ctx context.Context, table Table, ids []string, result airtableResultMapper, options ...ListOption) error
+	// Iterate streams the records of a table one page at a time, for tables too large to hold in memory.
+	Iterate(ctx context.Context, table Table, options ...ListOption) *Records
 }
 
 type airtableResultMapper interface {
@@ -55,51 +79,42 @@ type airtableRecordMapper interface {
 type airTableClient struct {
 	client    *http.Client
 	apiSecret string
+	baseUrl   string
+	limiter   *limiter
 }
 
-func NewClient(ctx context.Context, apiSecret string) (Client, error) {
+// NewClient creates a client for the production base, unless the options point it elsewhere.
+func NewClient(ctx context.Context, apiSecret string, options ...Option) (Client, error) {
+	configured := newClientOptions(options)
+	baseUrl := configured.baseUrl + url.PathEscape(configured.baseId) + "/"
+
 	return &airTableClient{
 		client: &http.Client{
-			Timeout: 20 * time.Second,
+			Timeout:   configured.timeout,
+			Transport: configured.transport,
 		},
 		apiSecret: apiSecret,
+		baseUrl:   baseUrl,
+		limiter:   baseLimiter(baseUrl, configured.requestsPerSecond),
 	}, nil
 }
 
-func (c *airTableClient) GetAll(ctx context.Context, table Table, result airtableResultMapper) error {
-	req, err := http.NewRequest(http.MethodGet, baseUrl+string(table), nil)
-	if err != nil {
-		log.Println("could not create request")
-		return err
-	}
-
-	body, err := c.fetchResult(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	var airtableResult AirtableResult
-	err = json.Unmarshal(body, &airtableResult)
-	if err != nil {
-		log.Println("error decoding result")
-		return err
-	}
-
-	err = result.MapAirtableResult(airtableResult)
-	if err != nil {
-		log.Println("error mapping airtable result")
-		return err
-	}
-	return nil
+func (c *airTableClient) GetAll(ctx context.Context, table Table, result airtableResultMapper, options ...ListOption) error {
+	return c.list(ctx, table, url.Values{}, options, result)
 }
 
-func (c *airTableClient) GetByIds(ctx context.Context, table Table, ids []string, result airtableResultMapper) error {
+func (c *airTableClient) GetByIds(ctx context.Context, table Table, ids []string, result airtableResultMapper, options ...ListOption) error {
 	if len(ids) == 0 {
 		return nil
 	}
 
-	filter := template.URLQueryEscaper("OR({Id}=\"" + strings.Join(ids, "\",{Id}=\"") + "\")")
-	req, err := http.NewRequest(http.MethodGet, baseUrl+string(table)+"?filterByFormula="+filter, nil)
+	query := url.Values{}
+	query.Set("filterByFormula", "OR({Id}=\""+strings.Join(ids, "\",{Id}=\"")+"\")")
+	return c.list(ctx, table, query, options, result)
+}
+
+func (c *airTableClient) Get(ctx context.Context, table Table, id string, result airtableRecordMapper) error {
+	req, err := http.NewRequest(http.MethodGet, c.tableUrl(table)+"/"+url.PathEscape(id), nil)
 	if err != nil {
 		log.Println("could not create request")
 		return err
@@ -110,14 +125,14 @@ func (c *airTableClient) GetByIds(ctx context.Context, table Table, ids []string
 		return err
 	}
 
-	var airtableResult AirtableResult
-	err = json.Unmarshal(body, &airtableResult)
+	var airtableRecord AirtableRecord
+	err = json.Unmarshal(body, &airtableRecord)
 	if err != nil {
 		log.Println("error decoding result")
 		return err
 	}
 
-	err = result.MapAirtableResult(airtableResult)
+	err = result.MapAirtableRecord(airtableRecord)
 	if err != nil {
 		log.Println("error mapping airtable result")
 		return err
@@ -125,26 +140,28 @@ func (c *airTableClient) GetByIds(ctx context.Context, table Table, ids []string
 	return nil
 }
 
-func (c *airTableClient) Get(ctx context.Context, table Table, id string, result airtableRecordMapper) error {
-	req, err := http.NewRequest(http.MethodGet, baseUrl+string(table)+"/"+id, nil)
-	if err != nil {
-		log.Println("could not create request")
-		return err
-	}
+func (c *airTableClient) GetByParentId(ctx context.Context, table Table, parentTable Table, parentId string, result airtableResultMapper, options ...ListOption) error {
+	query := url.Values{}
+	query.Set("filterByFormula", "{"+string(parentTable)+"}=\""+parentId+"\"")
+	return c.list(ctx, table, query, options, result)
+}
 
-	body, err := c.fetchResult(ctx, req)
-	if err != nil {
-		return err
-	}
+func (c *airTableClient) Iterate(ctx context.Context, table Table, options ...ListOption) *Records {
+	return c.records(ctx, table, url.Values{}, options)
+}
 
-	var airtableRecord AirtableRecord
-	err = json.Unmarshal(body, &airtableRecord)
-	if err != nil {
-		log.Println("error decoding result")
+// list collects the records of all pages and maps them at once, like a single page.
+func (c *airTableClient) list(ctx context.Context, table Table, query url.Values, options []ListOption, result airtableResultMapper) error {
+	records := c.records(ctx, table, query, options)
+	var airtableResult AirtableResult
+	for records.Next() {
+		airtableResult.Records = append(airtableResult.Records, records.Record())
+	}
+	if err := records.Err(); err != nil {
 		return err
 	}
 
-	err = result.MapAirtableRecord(airtableRecord)
+	err := result.MapAirtableResult(airtableResult)
 	if err != nil {
 		log.Println("error mapping airtable result")
 		return err
@@ -152,41 +169,81 @@ func (c *airTableClient) Get(ctx context.Context, table Table, id string, result
 	return nil
 }
 
-func (c *airTableClient) GetByParentId(ctx context.Context, table Table, parentTable Table, parentId string, result airtableResultMapper) error {
-	filter := template.URLQueryEscaper("{" + string(parentTable) + "}=\"" + parentId + "\"")
-	req, err := http.NewRequest(http.MethodGet, baseUrl+string(table)+"?filterByFormula="+filter, nil)
+func (c *airTableClient) tableUrl(table Table) string {
+	return c.baseUrl + url.PathEscape(string(table))
+}
+
+// fetchPage fetches one page of a list request, starting at the offset of the page before.
+func (c *airTableClient) fetchPage(ctx context.Context, table Table, query url.Values, offset string) (AirtableResult, error) {
+	pageQuery := url.Values{}
+	for key, values := range query {
+		pageQuery[key] = values
+	}
+	if offset != "" {
+		pageQuery.Set("offset", offset)
+	}
+
+	address := c.tableUrl(table)
+	if len(pageQuery) > 0 {
+		address += "?" + pageQuery.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, address, nil)
 	if err != nil {
 		log.Println("could not create request")
-		return err
+		return AirtableResult{}, err
 	}
 
 	body, err := c.fetchResult(ctx, req)
 	if err != nil {
-		return err
+		return AirtableResult{}, err
 	}
 
 	var airtableResult AirtableResult
 	err = json.Unmarshal(body, &airtableResult)
 	if err != nil {
 		log.Println("error decoding result")
-		return err
+		return AirtableResult{}, err
 	}
-
-	err = result.MapAirtableResult(airtableResult)
-	if err != nil {
-		log.Println("error mapping airtable result")
-		return err
-	}
-	return nil
+	return airtableResult, nil
 }
 
+// fetchResult sends the request, waiting for the rate limit of the base, and reads the body of the response.
+// Rate limited and failed requests are retried with exponential backoff, and other responses that are not a
+// success are returned as an *Error.
 func (c *airTableClient) fetchResult(ctx context.Context, req *http.Request) ([]byte, error) {
 	req.Header.Add("Authorization", "Bearer "+c.apiSecret)
 	req = req.WithContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := c.limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		body, retryAfter, err := c.fetchOnce(req)
+		airtableError, isAirtableError := err.(*Error)
+		if err == nil || !isAirtableError || !airtableError.Temporary() || attempt >= maxAttempts {
+			return body, err
+		}
+
+		delay := backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		log.Printf("airtable responded %d, retrying in %s", airtableError.StatusCode, delay)
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchOnce sends the request once. The duration is the wait the response asks for in its Retry-After header.
+func (c *airTableClient) fetchOnce(req *http.Request) ([]byte, time.Duration, error) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		log.Println("error calling airtable")
-		return nil, err
+		return nil, 0, err
 	}
 	defer func() {
 		err := resp.Body.Close()
@@ -197,7 +254,37 @@ func (c *airTableClient) fetchResult(ctx context.Context, req *http.Request) ([]
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Println("error reading body")
-		return nil, err
+		return nil, 0, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, retryAfter(resp.Header.Get("Retry-After")), newError(resp.StatusCode, body)
+	}
+	return body, 0, nil
+}
+
+// backoff is the wait before a retry, doubling for every attempt up to maxBackoff, with full jitter so that
+// clients that failed together do not retry together.
+func backoff(attempt int) time.Duration {
+	ceiling := maxBackoff
+	if attempt < 16 {
+		if exponential := initialBackoff << uint(attempt-1); exponential < maxBackoff {
+			ceiling = exponential
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+// retryAfter reads a Retry-After header, given either in seconds or as a date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
 	}
-	return body, nil
+	return 0
 }
@@ -0,0 +1,270 @@
+// Package airtabletest provides an in-memory Airtable for testing code that reads through airtable.Client,
+// like the resolvables, without network access.
+//
+//	server := airtabletest.NewServer()
+//	defer server.Close()
+//	server.Add(airtable.Workout, "rec1", map[string]interface{}{"Id": "rec1", "name": "Intervals"})
+//	client := server.Client(ctx)
+//
+// The server answers the list and get calls of the Airtable API for a single base, with pages, pageSize,
+// maxRecords and the filterByFormula formulas the client sends: a field compared with a string, or several
+// such comparisons joined with OR. A field matches when it equals the string, or when it is a list, like a
+// linked record field, that contains it. Tables without records are empty.
+package airtabletest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goapi/airtable"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	baseId      = "appTest"
+	maxPageSize = 100
+)
+
+var comparison = regexp.MustCompile(`^\{([^}]+)\}="((?:[^"\\]|\\.)*)"$`)
+
+// Server is a fake Airtable base served over HTTP.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	tables   map[airtable.Table][]airtable.AirtableRecord
+	failures []failure
+}
+
+type failure struct {
+	statusCode int
+	errorType  string
+	message    string
+}
+
+// NewServer starts an empty fake base. Close the server when done.
+func NewServer() *Server {
+	s := &Server{tables: map[airtable.Table][]airtable.AirtableRecord{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Add stores a record at the end of a table. The fields are encoded to JSON, and Add panics when they can not
+// be.
+func (s *Server) Add(table airtable.Table, id string, fields interface{}) {
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		panic(fmt.Sprintf("airtabletest: could not encode the fields of %s: %v", id, err))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tables[table] = append(s.tables[table], airtable.AirtableRecord{Id: id, Fields: encoded})
+}
+
+// Fail makes the next request respond with the status code and an Airtable error of the type and message.
+// Failures queue up, so calling Fail twice fails the next two requests.
+func (s *Server) Fail(statusCode int, errorType, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = append(s.failures, failure{statusCode: statusCode, errorType: errorType, message: message})
+}
+
+// Options point a client at the server, without the rate limit of the real Airtable.
+func (s *Server) Options() []airtable.Option {
+	return []airtable.Option{
+		airtable.BaseUrl(s.URL + "/v0/"),
+		airtable.BaseId(baseId),
+		airtable.Transport(s.Server.Client().Transport),
+		airtable.RequestsPerSecond(0),
+	}
+}
+
+// Client creates an airtable.Client that reads from the server.
+func (s *Server) Client(ctx context.Context) airtable.Client {
+	client, err := airtable.NewClient(ctx, "test", s.Options()...)
+	if err != nil {
+		panic(fmt.Sprintf("airtabletest: could not create client: %v", err))
+	}
+	return client
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.failures) > 0 {
+		failed := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, failed.statusCode, failed.errorType, failed.message)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "the fake Airtable is read only")
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "AUTHENTICATION_REQUIRED", "Authentication required")
+		return
+	}
+
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v0/"), "/")
+	if len(path) < 2 || len(path) > 3 || path[0] != baseId {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "")
+		return
+	}
+	records := s.tables[airtable.Table(path[1])]
+
+	if len(path) == 3 {
+		for _, record := range records {
+			if record.Id == path[2] {
+				writeJSON(w, http.StatusOK, record)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "")
+		return
+	}
+	s.list(w, r, records)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, records []airtable.AirtableRecord) {
+	query := r.URL.Query()
+	pageSize, err := intParameter(query.Get("pageSize"), maxPageSize)
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_PAGE_SIZE", "pageSize must be between 1 and 100")
+		return
+	}
+	maxRecords, err := intParameter(query.Get("maxRecords"), 0)
+	if err != nil || maxRecords < 0 {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_MAX_RECORDS", "maxRecords must be a positive number")
+		return
+	}
+	offset, err := intParameter(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusUnprocessableEntity, "LIST_RECORDS_ITERATOR_NOT_AVAILABLE", "")
+		return
+	}
+
+	if formula := query.Get("filterByFormula"); formula != "" {
+		records, err = filter(records, formula)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "INVALID_FILTER_BY_FORMULA", err.Error())
+			return
+		}
+	}
+	if maxRecords > 0 && maxRecords < len(records) {
+		records = records[:maxRecords]
+	}
+
+	result := airtable.AirtableResult{Records: []airtable.AirtableRecord{}}
+	if offset < len(records) {
+		end := offset + pageSize
+		if end < len(records) {
+			result.Offset = strconv.Itoa(end)
+		} else {
+			end = len(records)
+		}
+		result.Records = records[offset:end]
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// filter keeps the records that match any of the comparisons of the formula.
+func filter(records []airtable.AirtableRecord, formula string) ([]airtable.AirtableRecord, error) {
+	terms := []string{formula}
+	if strings.HasPrefix(formula, "OR(") && strings.HasSuffix(formula, ")") {
+		terms = splitTerms(strings.TrimSuffix(strings.TrimPrefix(formula, "OR("), ")"))
+	}
+
+	type fieldValue struct{ field, value string }
+	comparisons := make([]fieldValue, 0, len(terms))
+	for _, term := range terms {
+		match := comparison.FindStringSubmatch(strings.TrimSpace(term))
+		if match == nil {
+			return nil, fmt.Errorf("the fake Airtable does not support the formula %s", formula)
+		}
+		comparisons = append(comparisons, fieldValue{field: match[1], value: strings.Replace(match[2], `\"`, `"`, -1)})
+	}
+
+	matching := []airtable.AirtableRecord{}
+	for _, record := range records {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(record.Fields, &fields); err != nil {
+			return nil, err
+		}
+		for _, c := range comparisons {
+			if matches(fields[c.field], c.value) {
+				matching = append(matching, record)
+				break
+			}
+		}
+	}
+	return matching, nil
+}
+
+// splitTerms splits the arguments of a formula function at the commas outside of strings.
+func splitTerms(arguments string) []string {
+	terms := []string{}
+	inString, escaped, start := false, false, 0
+	for i, character := range arguments {
+		switch {
+		case escaped:
+			escaped = false
+		case character == '\\':
+			escaped = true
+		case character == '"':
+			inString = !inString
+		case character == ',' && !inString:
+			terms = append(terms, arguments[start:i])
+			start = i + 1
+		}
+	}
+	return append(terms, arguments[start:])
+}
+
+func matches(field interface{}, value string) bool {
+	switch typed := field.(type) {
+	case []interface{}:
+		for _, element := range typed {
+			if matches(element, value) {
+				return true
+			}
+		}
+		return false
+	case nil:
+		return value == ""
+	case string:
+		return typed == value
+	default:
+		return fmt.Sprint(typed) == value
+	}
+}
+
+func intParameter(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, errorType, message string) {
+	body := map[string]interface{}{"error": errorType}
+	if message != "" {
+		body["error"] = map[string]string{"type": errorType, "message": message}
+	}
+	writeJSON(w, statusCode, body)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		panic(fmt.Sprintf("airtabletest: could not encode response: %v", err))
+	}
+}
@@ -0,0 +1,281 @@
+package airtable
+
+import (
+	"container/list"
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheTTL  = 5 * time.Minute
+	DefaultCacheSize = 1000
+)
+
+type cacheOptions struct {
+	defaultTTL time.Duration
+	tableTTLs  map[Table]time.Duration
+	maxEntries int
+}
+
+// CacheOption configures a client created with NewCachingClient.
+type CacheOption func(*cacheOptions)
+
+// DefaultTTL sets how long results are kept for tables without a TTL of their own.
+func DefaultTTL(ttl time.Duration) CacheOption {
+	return func(options *cacheOptions) {
+		options.defaultTTL = ttl
+	}
+}
+
+// TableTTL sets how long the results of a table are kept. A TTL of 0 or less turns caching off for the table.
+func TableTTL(table Table, ttl time.Duration) CacheOption {
+	return func(options *cacheOptions) {
+		options.tableTTLs[table] = ttl
+	}
+}
+
+// MaxEntries sets how many results are kept before the least recently used are dropped.
+func MaxEntries(count int) CacheOption {
+	return func(options *cacheOptions) {
+		options.maxEntries = count
+	}
+}
+
+// CachingClient is a read-through cache in front of a Client. Results are kept in memory for the TTL of their
+// table, up to a maximum number of results, dropping the least recently used first. Identical requests that
+// arrive while the first is still in flight wait for its result instead of calling Airtable again. Iterate is
+// not cached, since it is meant for tables too large to keep in memory.
+type CachingClient struct {
+	client  Client
+	options cacheOptions
+
+	mutex       sync.Mutex
+	entries     map[string]*list.Element
+	recency     *list.List
+	inFlight    map[string]*call
+	generations map[Table]int
+}
+
+type cacheEntry struct {
+	key     string
+	table   Table
+	value   interface{}
+	expires time.Time
+}
+
+// call is a request in flight, which identical requests wait for.
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// NewCachingClient wraps a client with a cache.
+func NewCachingClient(client Client, options ...CacheOption) *CachingClient {
+	configured := cacheOptions{
+		defaultTTL: DefaultCacheTTL,
+		tableTTLs:  map[Table]time.Duration{},
+		maxEntries: DefaultCacheSize,
+	}
+	for _, option := range options {
+		option(&configured)
+	}
+
+	return &CachingClient{
+		client:      client,
+		options:     configured,
+		entries:     map[string]*list.Element{},
+		recency:     list.New(),
+		inFlight:    map[string]*call{},
+		generations: map[Table]int{},
+	}
+}
+
+func (c *CachingClient) GetAll(ctx context.Context, table Table, result airtableResultMapper, options ...ListOption) error {
+	key := listKey("all", table, nil, options)
+	return c.list(ctx, table, key, result, func(ctx context.Context, mapper airtableResultMapper) error {
+		return c.client.GetAll(ctx, table, mapper, options...)
+	})
+}
+
+func (c *CachingClient) Get(ctx context.Context, table Table, id string, result airtableRecordMapper) error {
+	value, err := c.fetch(ctx, table, "get "+string(table)+" "+id, func(ctx context.Context) (interface{}, error) {
+		var record recordCollector
+		err := c.client.Get(ctx, table, id, &record)
+		return AirtableRecord(record), err
+	})
+	if err != nil {
+		return err
+	}
+	return result.MapAirtableRecord(value.(AirtableRecord))
+}
+
+func (c *CachingClient) GetByParentId(ctx context.Context, table Table, parentTable Table, parentId string, result airtableResultMapper, options ...ListOption) error {
+	key := listKey("parent", table, []string{string(parentTable), parentId}, options)
+	return c.list(ctx, table, key, result, func(ctx context.Context, mapper airtableResultMapper) error {
+		return c.client.GetByParentId(ctx, table, parentTable, parentId, mapper, options...)
+	})
+}
+
+func (c *CachingClient) GetByIds(ctx context.Context, table Table, ids []string, result airtableResultMapper, options ...ListOption) error {
+	key := listKey("ids", table, ids, options)
+	return c.list(ctx, table, key, result, func(ctx context.Context, mapper airtableResultMapper) error {
+		return c.client.GetByIds(ctx, table, ids, mapper, options...)
+	})
+}
+
+func (c *CachingClient) Iterate(ctx context.Context, table Table, options ...ListOption) *Records {
+	return c.client.Iterate(ctx, table, options...)
+}
+
+// Invalidate drops the cached results of a table, so the next requests read it from Airtable again. Requests
+// that are in flight while it is invalidated are not cached either.
+func (c *CachingClient) Invalidate(table Table) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generations[table]++
+	for key, element := range c.entries {
+		if element.Value.(*cacheEntry).table == table {
+			c.recency.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *CachingClient) list(ctx context.Context, table Table, key string, result airtableResultMapper, fetch func(context.Context, airtableResultMapper) error) error {
+	value, err := c.fetch(ctx, table, key, func(ctx context.Context) (interface{}, error) {
+		var collected resultCollector
+		err := fetch(ctx, &collected)
+		return AirtableResult(collected), err
+	})
+	if err != nil {
+		return err
+	}
+	return result.MapAirtableResult(value.(AirtableResult))
+}
+
+// fetch returns the cached value of the key, or the value of the call in flight for it, or calls Airtable.
+// The call is shared by every caller waiting for it, so it runs detached from the context of the caller that
+// started it. Each caller stops waiting when its own context is done.
+func (c *CachingClient) fetch(ctx context.Context, table Table, key string, load func(context.Context) (interface{}, error)) (interface{}, error) {
+	ttl, exist := c.options.tableTTLs[table]
+	if !exist {
+		ttl = c.options.defaultTTL
+	}
+	if ttl <= 0 {
+		return load(ctx)
+	}
+
+	c.mutex.Lock()
+	if element, exist := c.entries[key]; exist {
+		entry := element.Value.(*cacheEntry)
+		if time.Now().Before(entry.expires) {
+			c.recency.MoveToFront(element)
+			c.mutex.Unlock()
+			return entry.value, nil
+		}
+		c.recency.Remove(element)
+		delete(c.entries, key)
+	}
+	pending, exist := c.inFlight[key]
+	if !exist {
+		pending = &call{done: make(chan struct{})}
+		c.inFlight[key] = pending
+		go c.load(ctx, table, key, ttl, c.generations[table], pending, load)
+	}
+	c.mutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-pending.done:
+	}
+	// A call cancelled by something other than this caller says nothing about the value, so it is not shared.
+	if isCancellation(pending.err) {
+		return load(ctx)
+	}
+	return pending.value, pending.err
+}
+
+// load runs a call in flight and caches its value, unless the table was invalidated while it ran.
+func (c *CachingClient) load(ctx context.Context, table Table, key string, ttl time.Duration, generation int, pending *call, load func(context.Context) (interface{}, error)) {
+	pending.value, pending.err = load(detachedContext{ctx})
+
+	c.mutex.Lock()
+	delete(c.inFlight, key)
+	if pending.err == nil && generation == c.generations[table] {
+		c.store(&cacheEntry{key: key, table: table, value: pending.value, expires: time.Now().Add(ttl)})
+	}
+	c.mutex.Unlock()
+	close(pending.done)
+}
+
+func isCancellation(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
+
+// detachedContext keeps the values of a context, like its logger, but not its deadline or cancellation. Calls to
+// Airtable are still limited by the timeout of the client.
+type detachedContext struct {
+	values context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.values.Value(key)
+}
+
+// store adds an entry, dropping the least recently used entries when the cache is full. The mutex must be held.
+func (c *CachingClient) store(entry *cacheEntry) {
+	c.entries[entry.key] = c.recency.PushFront(entry)
+	for c.options.maxEntries > 0 && c.recency.Len() > c.options.maxEntries {
+		oldest := c.recency.Back()
+		c.recency.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// listKey identifies a list request by its kind, table, arguments and options.
+func listKey(kind string, table Table, arguments []string, options []ListOption) string {
+	var configured listOptions
+	for _, option := range options {
+		option(&configured)
+	}
+	query := url.Values{
+		"arguments":  arguments,
+		"pageSize":   {strconv.Itoa(configured.pageSize)},
+		"maxRecords": {strconv.Itoa(configured.maxRecords)},
+	}
+	return kind + " " + string(table) + "?" + query.Encode()
+}
+
+// resultCollector and recordCollector keep what the wrapped client fetched, so it can be cached and mapped
+// for every caller.
+type resultCollector AirtableResult
+
+func (r *resultCollector) MapAirtableResult(result AirtableResult) error {
+	*r = resultCollector(result)
+	return nil
+}
+
+type recordCollector AirtableRecord
+
+func (r *recordCollector) MapAirtableRecord(record AirtableRecord) error {
+	*r = recordCollector(record)
+	return nil
+}
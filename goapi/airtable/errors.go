@@ -0,0 +1,62 @@
+package airtable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Error is a response from Airtable that is not a success, with the error type and message Airtable gave.
+type Error struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("airtable responded %d %s", e.StatusCode, e.Type)
+	}
+	return fmt.Sprintf("airtable responded %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Temporary tells whether the request may succeed when it is sent again.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// IsNotFound tells whether the error is Airtable not finding the table or record.
+func IsNotFound(err error) bool {
+	airtableError, ok := err.(*Error)
+	return ok && airtableError.StatusCode == http.StatusNotFound
+}
+
+// newError reads the error of a response. Airtable either sends an object with the type and message, or just
+// the type as a string.
+func newError(statusCode int, body []byte) *Error {
+	airtableError := &Error{StatusCode: statusCode, Type: http.StatusText(statusCode)}
+
+	var response struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Error) == 0 {
+		return airtableError
+	}
+
+	var details struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(response.Error, &details); err == nil {
+		if details.Type != "" {
+			airtableError.Type = details.Type
+		}
+		airtableError.Message = details.Message
+		return airtableError
+	}
+	var errorType string
+	if err := json.Unmarshal(response.Error, &errorType); err == nil && errorType != "" {
+		airtableError.Type = errorType
+	}
+	return airtableError
+}
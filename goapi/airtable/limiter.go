@@ -0,0 +1,73 @@
+package airtable
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestsPerSecond is the number of requests Airtable allows per second and base. Going over it locks the
+// client out for 30 seconds.
+const requestsPerSecond = 5
+
+var (
+	limitersMutex sync.Mutex
+	limiters      = map[string]*limiter{}
+)
+
+// limiter spaces requests evenly, so that no more than a given number start per second.
+type limiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// baseLimiter is the limiter of a base. All clients of the same base share it, since Airtable counts the
+// requests per base. A rate of 0 or less gives a limiter that never waits.
+func baseLimiter(base string, rate int) *limiter {
+	if rate <= 0 {
+		return &limiter{}
+	}
+
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
+
+	key := base + " " + strconv.Itoa(rate)
+	l, exist := limiters[key]
+	if !exist {
+		l = &limiter{interval: time.Second / time.Duration(rate)}
+		limiters[key] = l
+	}
+	return l
+}
+
+// wait blocks until the request may start, or the context is done.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mutex.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mutex.Unlock()
+
+	return sleep(ctx, slot.Sub(now))
+}
+
+// sleep waits for the duration, or until the context is done.
+func sleep(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
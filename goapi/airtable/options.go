@@ -0,0 +1,76 @@
+package airtable
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseUrl = "https://api.airtable.com/v0/"
+	DefaultBaseId  = "appKpRGYhVdY3IspT"
+	DefaultTimeout = 20 * time.Second
+)
+
+type clientOptions struct {
+	baseUrl           string
+	baseId            string
+	timeout           time.Duration
+	transport         http.RoundTripper
+	requestsPerSecond int
+}
+
+// Option configures a client created with NewClient.
+type Option func(*clientOptions)
+
+// BaseUrl sets the address of the Airtable API, like an httptest.Server in tests.
+func BaseUrl(baseUrl string) Option {
+	return func(options *clientOptions) {
+		options.baseUrl = baseUrl
+	}
+}
+
+// BaseId sets the base the tables are read from, like a staging copy of the production base.
+func BaseId(baseId string) Option {
+	return func(options *clientOptions) {
+		options.baseId = baseId
+	}
+}
+
+// Timeout sets how long a single request may take, including reading the response.
+func Timeout(timeout time.Duration) Option {
+	return func(options *clientOptions) {
+		options.timeout = timeout
+	}
+}
+
+// Transport sets the round tripper that sends the requests, instead of http.DefaultTransport.
+func Transport(transport http.RoundTripper) Option {
+	return func(options *clientOptions) {
+		options.transport = transport
+	}
+}
+
+// RequestsPerSecond changes the rate limit of the client. Airtable allows 5 requests per second, so this is
+// only meant for fake servers. A rate of 0 or less turns the limit off.
+func RequestsPerSecond(rate int) Option {
+	return func(options *clientOptions) {
+		options.requestsPerSecond = rate
+	}
+}
+
+func newClientOptions(options []Option) clientOptions {
+	configured := clientOptions{
+		baseUrl:           DefaultBaseUrl,
+		baseId:            DefaultBaseId,
+		timeout:           DefaultTimeout,
+		requestsPerSecond: requestsPerSecond,
+	}
+	for _, option := range options {
+		option(&configured)
+	}
+	if !strings.HasSuffix(configured.baseUrl, "/") {
+		configured.baseUrl += "/"
+	}
+	return configured
+}
@@ -0,0 +1,117 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// maxPageSize is the largest page Airtable returns, and the page size it uses by default.
+const maxPageSize = 100
+
+var ErrInvalidPageSize = errors.New("the page size must be between 1 and 100")
+
+type listOptions struct {
+	pageSize   int
+	maxRecords int
+}
+
+// ListOption limits the records of a list call.
+type ListOption func(*listOptions)
+
+// PageSize sets the number of records fetched per request, at most 100.
+func PageSize(size int) ListOption {
+	return func(options *listOptions) {
+		options.pageSize = size
+	}
+}
+
+// MaxRecords stops a list call after the given number of records.
+func MaxRecords(count int) ListOption {
+	return func(options *listOptions) {
+		options.maxRecords = count
+	}
+}
+
+// Records iterates over the records of a list call. Pages are fetched as they are needed, so only one page is
+// held in memory at a time.
+//
+//	records := client.Iterate(ctx, airtable.Workout)
+//	for records.Next() {
+//		record := records.Record()
+//		...
+//	}
+//	if err := records.Err(); err != nil {
+//		...
+//	}
+type Records struct {
+	ctx     context.Context
+	client  *airTableClient
+	table   Table
+	query   url.Values
+	options listOptions
+
+	page    []AirtableRecord
+	record  AirtableRecord
+	offset  string
+	fetched bool
+	count   int
+	err     error
+}
+
+func (c *airTableClient) records(ctx context.Context, table Table, query url.Values, options []ListOption) *Records {
+	records := &Records{ctx: ctx, client: c, table: table, query: query}
+	for _, option := range options {
+		option(&records.options)
+	}
+
+	if size := records.options.pageSize; size != 0 {
+		if size < 0 || size > maxPageSize {
+			records.err = ErrInvalidPageSize
+			return records
+		}
+		query.Set("pageSize", strconv.Itoa(size))
+	}
+	if records.options.maxRecords > 0 {
+		query.Set("maxRecords", strconv.Itoa(records.options.maxRecords))
+	}
+	return records
+}
+
+// Next moves to the next record, fetching the next page when needed. It returns false when there are no more
+// records or fetching a page failed.
+func (r *Records) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if max := r.options.maxRecords; max > 0 && r.count >= max {
+		return false
+	}
+
+	for len(r.page) == 0 {
+		if r.fetched && r.offset == "" {
+			return false
+		}
+		result, err := r.client.fetchPage(r.ctx, r.table, r.query, r.offset)
+		if err != nil {
+			r.err = err
+			return false
+		}
+		r.page, r.offset, r.fetched = result.Records, result.Offset, true
+	}
+
+	r.record, r.page = r.page[0], r.page[1:]
+	r.count++
+	return true
+}
+
+// Record is the current record.
+func (r *Records) Record() AirtableRecord {
+	return r.record
+}
+
+// Err is the error that stopped the iteration, if any.
+func (r *Records) Err() error {
+	return r.err
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"github.com/gofrs/uuid"
+	"goapi/airtable"
+	"goapi/database"
+	"goapi/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxNameLength     = 50
+	maxPlanNameLength = 100
+	daysPerWeek       = 7
+	dateLayout        = "2006-01-02"
+)
+
+// converter turns the records of the base into database rows. Records that were imported before keep the id
+// they got, and new records get a new id.
+type converter struct {
+	base     base
+	existing map[string]string
+	ids      map[string]string
+	owner    string
+	imported database.AirtableImport
+	report   *report
+}
+
+// convert maps the base to the rows to import. The owner creates the intensities, workouts and plans, which
+// have no creator in Airtable. It is either the id of a profile in the database, or the Airtable id of a
+// profile in the base. The profiles map the Airtable ids of profiles to the profiles they are in the database.
+func convert(read base, existing map[string]string, used usage, owner string, profiles map[string]string) (database.AirtableImport, *report) {
+	c := &converter{base: read, existing: existing, ids: map[string]string{}, report: newReport()}
+	for _, table := range airtable.Tables {
+		c.report.counts[table].read = len(read[table])
+	}
+
+	c.convertProfiles(profiles)
+	c.resolveOwner(owner)
+	c.convertRecords()
+	c.convertIntensities()
+	c.convertWorkouts()
+	weekIds := c.convertPlans()
+	dayIds := c.convertWeeks(weekIds)
+	c.convertDays(dayIds)
+
+	for _, table := range airtable.Tables {
+		c.report.unmapped[table] = used.unmapped(table, read[table])
+	}
+	return c.imported, c.report
+}
+
+// id is the database id of a record, which is new unless the record was imported before.
+func (c *converter) id(table airtable.Table, airtableId string) string {
+	if id, exist := c.ids[airtableId]; exist {
+		return id
+	}
+	id, exist := c.existing[airtableId]
+	if !exist {
+		id = uuid.Must(uuid.NewV4()).String()
+		c.report.counts[table].new++
+	}
+	c.alias(table, airtableId, id)
+	return id
+}
+
+// alias maps a record to the database id of another, for duplicates that are merged.
+func (c *converter) alias(table airtable.Table, airtableId, id string) {
+	c.ids[airtableId] = id
+	c.imported.Ids = append(c.imported.Ids, database.AirtableId{AirtableId: airtableId, Table: string(table), Id: id})
+	c.report.counts[table].imported++
+}
+
+// convertProfiles imports the profiles of the base. Profiles mapped to a profile in the database are kept as
+// they are. The others are created, or updated when they were imported before, and can not log in: a login
+// gets a profile of its own when it is first used.
+func (c *converter) convertProfiles(profiles map[string]string) {
+	inBase := map[string]bool{}
+	for _, r := range c.base[airtable.Profile] {
+		inBase[r.id] = true
+		r.fields.ignore("id")
+		firstName, lastName := r.fields.string("firstName"), r.fields.string("lastName")
+		if firstName == "" && lastName == "" {
+			names := strings.SplitN(r.fields.string("name"), " ", 2)
+			firstName = names[0]
+			if len(names) > 1 {
+				lastName = names[1]
+			}
+		}
+		vdot := int(math.Round(r.fields.number("vdot")))
+
+		if id, mapped := profiles[r.id]; mapped {
+			c.alias(airtable.Profile, r.id, id)
+			continue
+		}
+		if _, exist := c.existing[r.id]; !exist {
+			c.report.note("The profile %s is created without a login. Map it to the profile of its login with "+
+				"-profile %s=<profile id>.", r.id, r.id)
+		}
+
+		c.imported.Profiles = append(c.imported.Profiles, models.Profile{
+			Id:        c.id(airtable.Profile, r.id),
+			FirstName: c.truncate(airtable.Profile, r.id, firstName, maxNameLength),
+			LastName:  c.truncate(airtable.Profile, r.id, lastName, maxNameLength),
+			Vdot:      vdot,
+		})
+	}
+
+	missing := []string{}
+	for airtableId := range profiles {
+		if !inBase[airtableId] {
+			missing = append(missing, airtableId)
+		}
+	}
+	sort.Strings(missing)
+	for _, airtableId := range missing {
+		c.report.note("The profile %s given with -profile is not in the base.", airtableId)
+		c.report.blocking = true
+	}
+}
+
+func (c *converter) resolveOwner(owner string) {
+	needsOwner := len(c.base[airtable.Intensity])+len(c.base[airtable.Workout])+len(c.base[airtable.Plan]) > 0
+	switch {
+	case owner == "" && len(c.base[airtable.Profile]) == 1:
+		c.owner = c.ids[c.base[airtable.Profile][0].id]
+		c.report.note("The intensities, workouts and plans are created by the only profile in the base, %s.",
+			c.base[airtable.Profile][0].id)
+	case owner == "":
+		if needsOwner {
+			c.report.note("The intensities, workouts and plans need a creator. Pass the profile with -owner.")
+			c.report.blocking = true
+		}
+	case strings.HasPrefix(owner, "rec"):
+		id, exist := c.ids[owner]
+		if !exist {
+			c.report.note("The owner %s is not a profile in the base.", owner)
+			c.report.blocking = true
+		}
+		c.owner = id
+	default:
+		if _, err := uuid.FromString(owner); err != nil {
+			c.report.note("The owner %s is neither a profile id nor the Airtable id of a profile.", owner)
+			c.report.blocking = true
+		}
+		c.owner = owner
+	}
+}
+
+func (c *converter) convertRecords() {
+	for _, r := range c.base[airtable.Record] {
+		r.fields.ignore("id")
+		profileId, ok := c.linked(airtable.Record, r.id, r.fields.link("profile"), airtable.Profile)
+		if !ok {
+			continue
+		}
+
+		record := models.Record{
+			Id:        c.id(airtable.Record, r.id),
+			ProfileId: profileId,
+			Race:      c.truncate(airtable.Record, r.id, r.fields.string("race"), maxNameLength),
+			Distance:  int(math.Round(r.fields.number("distance"))),
+			Duration:  int(math.Round(r.fields.number("duration"))),
+		}
+		if value := r.fields.string("date"); value != "" {
+			date, err := time.Parse(dateLayout, value)
+			if err != nil {
+				c.report.problem(airtable.Record, r.id, "the date %q is not formatted as YYYY-MM-DD, and is left out", value)
+			}
+			record.Date = date
+		}
+		c.imported.Records = append(c.imported.Records, record)
+	}
+}
+
+func (c *converter) convertIntensities() {
+	for _, r := range c.base[airtable.Intensity] {
+		r.fields.ignore("id")
+		c.imported.Intensities = append(c.imported.Intensities, models.Intensity{
+			Id:          c.id(airtable.Intensity, r.id),
+			Name:        c.name(airtable.Intensity, r.id, r.fields.string("name"), maxNameLength),
+			Description: r.fields.string("description"),
+			Coefficient: r.fields.number("coefficient"),
+			CreatedBy:   c.owner,
+		})
+	}
+}
+
+// convertWorkouts converts the workouts with their parts. The parts are the workout intensities, which link
+// to a workout and an intensity, in the order of their order field or else the order Airtable lists them.
+func (c *converter) convertWorkouts() {
+	workouts := map[string]*database.ImportedWorkout{}
+	for _, r := range c.base[airtable.Workout] {
+		// The distance is a rollup of the parts.
+		r.fields.ignore("id", "distance")
+		description := r.fields.string("description")
+		if purpose := r.fields.string("purpose"); purpose != "" {
+			description = strings.TrimSpace(purpose + "\n\n" + description)
+		}
+
+		c.imported.Workouts = append(c.imported.Workouts, database.ImportedWorkout{Workout: models.Workout{
+			Id:          c.id(airtable.Workout, r.id),
+			Name:        c.name(airtable.Workout, r.id, r.fields.string("name"), maxNameLength),
+			Description: description,
+			CreatedBy:   c.owner,
+		}})
+	}
+	for i := range c.imported.Workouts {
+		workouts[c.imported.Workouts[i].Id] = &c.imported.Workouts[i]
+	}
+
+	type orderedPart struct {
+		order    float64
+		position int
+		part     models.WorkoutPart
+	}
+	parts := map[string][]orderedPart{}
+	for position, r := range c.base[airtable.WorkoutIntensity] {
+		// The coefficient, name and description are lookups of the intensity.
+		r.fields.ignore("id", "coefficient", "name", "description")
+		workoutId, ok := c.linked(airtable.WorkoutIntensity, r.id, r.fields.link("workout"), airtable.Workout)
+		if !ok {
+			continue
+		}
+		intensityId, ok := c.intensity(r)
+		if !ok {
+			continue
+		}
+		metric, distance, ok := convertMetric(r.fields.string("metric"), int(math.Round(r.fields.number("distance"))))
+		if !ok || distance <= 0 {
+			c.report.problem(airtable.WorkoutIntensity, r.id, "the distance %v %s can not be converted to seconds or meters",
+				r.fields.number("distance"), r.fields.string("metric"))
+			continue
+		}
+
+		parts[workoutId] = append(parts[workoutId], orderedPart{
+			order:    r.fields.number("order"),
+			position: position,
+			part: models.WorkoutPart{
+				Id:        c.id(airtable.WorkoutIntensity, r.id),
+				Distance:  distance,
+				Metric:    metric,
+				Intensity: models.Intensity{Id: intensityId},
+			},
+		})
+	}
+
+	for workoutId, workoutParts := range parts {
+		sort.SliceStable(workoutParts, func(i, j int) bool {
+			if workoutParts[i].order != workoutParts[j].order {
+				return workoutParts[i].order < workoutParts[j].order
+			}
+			return workoutParts[i].position < workoutParts[j].position
+		})
+		for order, ordered := range workoutParts {
+			ordered.part.Order = order
+			workouts[workoutId].Parts = append(workouts[workoutId].Parts, ordered.part)
+		}
+	}
+}
+
+// intensity finds the intensity of a workout intensity. The intensity field is usually a link, but may be a
+// lookup of the intensity name.
+func (c *converter) intensity(r record) (string, bool) {
+	link := r.fields.link("intensity")
+	if id, exist := c.ids[link]; exist {
+		return id, true
+	}
+	for _, intensity := range c.imported.Intensities {
+		if link != "" && strings.EqualFold(intensity.Name, link) {
+			return intensity.Id, true
+		}
+	}
+	_, ok := c.linked(airtable.WorkoutIntensity, r.id, link, airtable.Intensity)
+	return "", ok
+}
+
+// convertPlans converts the plans, and returns the plan each week is listed in.
+func (c *converter) convertPlans() map[string]string {
+	planOfWeek := map[string]string{}
+	for _, r := range c.base[airtable.Plan] {
+		r.fields.ignore("id")
+		plan := models.Plan{
+			Id:          c.id(airtable.Plan, r.id),
+			Name:        c.name(airtable.Plan, r.id, r.fields.string("name"), maxPlanNameLength),
+			Description: r.fields.string("description"),
+			CreatedBy:   c.owner,
+		}
+		c.imported.Plans = append(c.imported.Plans, plan)
+
+		for _, weekId := range r.fields.links("weeks") {
+			if other, exist := planOfWeek[weekId]; exist {
+				c.report.problem(airtable.Week, weekId, "is listed in several plans, and is only imported to %s", other)
+				continue
+			}
+			planOfWeek[weekId] = r.id
+		}
+	}
+	return planOfWeek
+}
+
+// convertWeeks converts the weeks of the plans, numbering them from 0 in the order of their order field, and
+// returns the week each day is listed in.
+func (c *converter) convertWeeks(planOfWeek map[string]string) map[string]string {
+	type orderedWeek struct {
+		order    float64
+		position int
+		week     models.Week
+	}
+	weeks := map[string][]orderedWeek{}
+	planOrder := []string{}
+	weekOfDay := map[string]string{}
+	for position, r := range c.base[airtable.Week] {
+		// The distance is a rollup of the days.
+		r.fields.ignore("id", "distance")
+		planLink, listed := planOfWeek[r.id]
+		if fallback := r.fields.link("plan"); !listed {
+			planLink = fallback
+		}
+		planId, ok := c.linked(airtable.Week, r.id, planLink, airtable.Plan)
+		if !ok {
+			continue
+		}
+
+		week := models.Week{Id: c.id(airtable.Week, r.id), PlanId: planId}
+		if _, exist := weeks[planId]; !exist {
+			planOrder = append(planOrder, planId)
+		}
+		weeks[planId] = append(weeks[planId], orderedWeek{order: r.fields.number("order"), position: position, week: week})
+
+		for _, dayId := range r.fields.links("days") {
+			if other, exist := weekOfDay[dayId]; exist {
+				c.report.problem(airtable.Day, dayId, "is listed in several weeks, and is only imported to %s", other)
+				continue
+			}
+			weekOfDay[dayId] = r.id
+		}
+	}
+
+	for _, planId := range planOrder {
+		planWeeks := weeks[planId]
+		sort.SliceStable(planWeeks, func(i, j int) bool {
+			if planWeeks[i].order != planWeeks[j].order {
+				return planWeeks[i].order < planWeeks[j].order
+			}
+			return planWeeks[i].position < planWeeks[j].position
+		})
+		for order, ordered := range planWeeks {
+			ordered.week.Order = order
+			c.imported.Weeks = append(c.imported.Weeks, ordered.week)
+		}
+	}
+	return weekOfDay
+}
+
+// convertDays converts the days of the weeks with their workouts. Days are numbered from 0, but a base that
+// numbers them from 1 to 7 is recognized. Days of the same week with the same number are merged.
+func (c *converter) convertDays(weekOfDay map[string]string) {
+	offset := 0
+	hasZero, hasSeven := false, false
+	for _, r := range c.base[airtable.Day] {
+		switch r.fields.number("day") {
+		case 0:
+			hasZero = true
+		case daysPerWeek:
+			hasSeven = true
+		}
+	}
+	if hasSeven && !hasZero {
+		offset = 1
+		c.report.note("The days are numbered from 1 to 7, and are imported as 0 to 6.")
+	}
+
+	type weekDay struct {
+		weekId string
+		day    int
+	}
+	days := map[weekDay]string{}
+	for _, r := range c.base[airtable.Day] {
+		// The distance is a rollup of the workouts.
+		r.fields.ignore("id", "distance")
+		weekLink, listed := weekOfDay[r.id]
+		if fallback := r.fields.link("week"); !listed {
+			weekLink = fallback
+		}
+		weekId, ok := c.linked(airtable.Day, r.id, weekLink, airtable.Week)
+		if !ok {
+			continue
+		}
+		number := r.fields.number("day")
+		day := int(number) - offset
+		if float64(int(number)) != number || day < 0 || day >= daysPerWeek {
+			c.report.problem(airtable.Day, r.id, "the day %v is not a day of the week", number)
+			continue
+		}
+
+		key := weekDay{weekId: weekId, day: day}
+		dayId, duplicate := days[key]
+		if duplicate {
+			c.report.problem(airtable.Day, r.id, "is the same day of the week as another day, and their workouts are merged")
+			c.alias(airtable.Day, r.id, dayId)
+		} else {
+			dayId = c.id(airtable.Day, r.id)
+			days[key] = dayId
+			c.imported.Days = append(c.imported.Days, models.Day{Id: dayId, WeekId: weekId, Day: day})
+		}
+
+		order := 0
+		for _, dayWorkout := range c.imported.DayWorkouts {
+			if dayWorkout.DayId == dayId {
+				order++
+			}
+		}
+		for _, workoutLink := range r.fields.links("workouts") {
+			workoutId, exist := c.ids[workoutLink]
+			if !exist {
+				c.report.problem(airtable.Day, r.id, "links to the workout %s, which does not exist, and the link is dropped", workoutLink)
+				continue
+			}
+			c.imported.DayWorkouts = append(c.imported.DayWorkouts, database.DayWorkout{DayId: dayId, WorkoutId: workoutId, Order: order})
+			order++
+		}
+	}
+}
+
+// linked finds the database id of a linked record. Records without the link, or with a link to a record that
+// is not imported, are orphans, which are reported and skipped.
+func (c *converter) linked(table airtable.Table, id, link string, parent airtable.Table) (string, bool) {
+	if link == "" {
+		c.report.problem(table, id, "is not linked to a %s, and is skipped", strings.ToLower(string(parent)))
+		return "", false
+	}
+	parentId, exist := c.ids[link]
+	if !exist {
+		c.report.problem(table, id, "links to the %s %s, which is not imported, and is skipped", strings.ToLower(string(parent)), link)
+		return "", false
+	}
+	return parentId, true
+}
+
+// name is a name that fits its column, with a placeholder for names that are missing.
+func (c *converter) name(table airtable.Table, id, name string, maxLength int) string {
+	if name == "" {
+		c.report.problem(table, id, "has no name, and is named %q", "Unnamed "+strings.ToLower(string(table)))
+		return "Unnamed " + strings.ToLower(string(table))
+	}
+	return c.truncate(table, id, name, maxLength)
+}
+
+func (c *converter) truncate(table airtable.Table, id, value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	c.report.problem(table, id, "%q is longer than %d characters, and is cut", value, maxLength)
+	return string(runes[:maxLength])
+}
+
+// convertMetric converts an Airtable distance to the seconds or meters of a workout part.
+func convertMetric(metric string, distance int) (string, int, bool) {
+	switch normalize(metric) {
+	case "second", "seconds", "sec", "s":
+		return "second", distance, true
+	case "minute", "minutes", "min":
+		return "second", distance * 60, true
+	case "hour", "hours", "h":
+		return "second", distance * 3600, true
+	case "meter", "meters", "m":
+		return "meter", distance, true
+	case "kilometer", "kilometers", "km":
+		return "meter", distance * 1000, true
+	default:
+		return "", 0, false
+	}
+}
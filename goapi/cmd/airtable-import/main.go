@@ -0,0 +1,142 @@
+// Command airtable-import copies the Airtable base into Postgres, for moving off Airtable.
+//
+// It reads every table of the base, and writes a report of what it found: how many records each table has and
+// how many are new, the records that are skipped or changed, like orphans whose parent does not exist, and the
+// fields it does not know. Then it imports everything in one transaction. The Airtable id of every imported
+// record is kept in the airtable_import table, so the command can be run again: records that were imported
+// before are updated instead of copied.
+//
+// Airtable does not know who created the intensities, workouts and plans, so they are created by the profile
+// given with -owner, either a profile id or the Airtable id of a profile in the base. The owner is only optional
+// when the base has a single profile.
+//
+// Profiles in the base are created without a login. A profile whose user has logged in already is mapped to the
+// profile of the login with -profile, which can be given once for every profile. Mapped profiles are kept as
+// they are, and get the records of the base.
+//
+// It is configured like the API, and must run from the goapi directory so the database migrations are found:
+//
+//	go run ./cmd/airtable-import -dry-run
+//	go run ./cmd/airtable-import -owner recXXXXXXXXXXXXXX
+//	go run ./cmd/airtable-import -profile recXXXXXXXXXXXXXX=8f14e45f-ceea-467a-9575-1d5b4e4a4b5c
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/sirupsen/logrus"
+	"goapi/airtable"
+	"goapi/appcontext"
+	"goapi/appcontext/initctx"
+	"goapi/config"
+	"goapi/database"
+	"goapi/logger"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report what would be imported")
+	owner := flag.String("owner", "", "the profile that creates the intensities, workouts and plans")
+	profiles := profileMapping{}
+	flag.Var(profiles, "profile", "maps the Airtable id of a profile to a profile id, like recXXXXXXXXXXXXXX=<profile id>")
+	flag.Parse()
+
+	cfg := config.FromEnv()
+
+	appcontext.AppName = "airtable-import"
+	appcontext.AppPodName, _ = os.Hostname()
+	appcontext.AppStartTime = time.Now().UTC()
+	appcontext.LogFactory = appcontext.NewLogFactory(&cfg, logrus.Fields{
+		"app":          appcontext.AppName,
+		"appPodName":   appcontext.AppPodName,
+		"appStartTime": appcontext.AppStartTime.Format(time.RFC3339Nano),
+	})
+
+	ctx, _ := initctx.InitializeContext(context.Background(), logrus.Fields{})
+	log := logger.FromContext(ctx)
+
+	airtableClient, err := airtable.NewClient(ctx, cfg.AirtableSecret,
+		airtable.BaseUrl(cfg.AirtableBaseUrl),
+		airtable.BaseId(cfg.AirtableBaseId),
+		airtable.Timeout(cfg.AirtableTimeout),
+	)
+	if err != nil {
+		log.WithError(err).Fatal("failed to create airtable client")
+	}
+
+	databaseClient, err := database.NewClient(ctx, cfg)
+	if err != nil {
+		log.WithError(err).Fatal("failed to create database client")
+	}
+	defer func() {
+		err := databaseClient.Close()
+		if err != nil {
+			log.WithError(err).Error("Error while closing db connection")
+		}
+	}()
+
+	log.Info("reading airtable")
+	used := usage{}
+	read, err := readBase(ctx, airtableClient, used)
+	if err != nil {
+		log.WithError(err).Fatal("failed to read airtable")
+	}
+	existing, err := databaseClient.GetAirtableIds(ctx)
+	if err != nil {
+		log.WithError(err).Fatal("failed to read the records imported before")
+	}
+
+	for airtableId, id := range profiles {
+		_, err := databaseClient.GetProfile(ctx, id)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to read the profile %s given for %s", id, airtableId)
+		}
+	}
+
+	imported, report := convert(read, existing, used, *owner, profiles)
+	if err := report.write(os.Stdout); err != nil {
+		log.WithError(err).Fatal("failed to write report")
+	}
+	if *dryRun {
+		return
+	}
+	if report.blocking {
+		log.Fatal("nothing is imported until the notes of the report are resolved")
+	}
+
+	log.Info("importing")
+	err = databaseClient.ImportAirtable(ctx, imported)
+	if err != nil {
+		log.WithError(err).Fatal("failed to import")
+	}
+	log.Info("imported")
+}
+
+// profileMapping maps the Airtable ids of profiles to the ids of profiles in the database.
+type profileMapping map[string]string
+
+func (m profileMapping) String() string {
+	mappings := []string{}
+	for airtableId, id := range m {
+		mappings = append(mappings, airtableId+"="+id)
+	}
+	return strings.Join(mappings, ",")
+}
+
+func (m profileMapping) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "rec") {
+		return errors.New("expected the Airtable id of a profile and a profile id, like recXXXXXXXXXXXXXX=<profile id>")
+	}
+	id, err := uuid.FromString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%s is not a profile id", parts[1])
+	}
+	m[parts[0]] = id.String()
+	return nil
+}
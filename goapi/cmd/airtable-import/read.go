@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"goapi/airtable"
+	"strings"
+)
+
+// record is an Airtable record with its fields left undecoded, so that the fields the import does not know
+// show up in the report.
+type record struct {
+	id     string
+	fields fields
+}
+
+// base holds the records of every table, in the order Airtable lists them.
+type base map[airtable.Table][]record
+
+// readBase reads all the records of all the tables. The pages are streamed, but all the records are kept,
+// since links between tables can only be checked when everything is read.
+func readBase(ctx context.Context, client airtable.Client, used usage) (base, error) {
+	read := base{}
+	for _, table := range airtable.Tables {
+		records := client.Iterate(ctx, table)
+		for records.Next() {
+			airtableRecord := records.Record()
+			var values map[string]json.RawMessage
+			err := json.Unmarshal(airtableRecord.Fields, &values)
+			if err != nil {
+				return nil, err
+			}
+			read[table] = append(read[table], record{
+				id:     airtableRecord.Id,
+				fields: fields{table: table, values: values, used: used},
+			})
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return read, nil
+}
+
+// usage collects the names of the fields the import read or deliberately ignored, by table.
+type usage map[airtable.Table]map[string]bool
+
+// unmapped lists the fields of a table that are in the records but were not read.
+func (u usage) unmapped(table airtable.Table, records []record) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, r := range records {
+		for name := range r.fields.values {
+			if !seen[name] && !u[table][normalize(name)] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// fields reads the fields of a record by name, ignoring case, spaces and underscores, so "First name" and
+// "firstName" are the same field. Every field that is asked for is marked as used.
+type fields struct {
+	table  airtable.Table
+	values map[string]json.RawMessage
+	used   usage
+}
+
+func (f fields) lookup(name string) (json.RawMessage, bool) {
+	f.ignore(name)
+	for key, value := range f.values {
+		if normalize(key) == normalize(name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// ignore marks fields as known without reading them, like lookups and rollups that are computed from other
+// tables.
+func (f fields) ignore(names ...string) {
+	if f.used[f.table] == nil {
+		f.used[f.table] = map[string]bool{}
+	}
+	for _, name := range names {
+		f.used[f.table][normalize(name)] = true
+	}
+}
+
+func (f fields) string(name string) string {
+	var value string
+	if raw, exist := f.lookup(name); exist {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return strings.TrimSpace(value)
+}
+
+func (f fields) number(name string) float64 {
+	var value float64
+	if raw, exist := f.lookup(name); exist {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+// links reads a linked record field, which holds the ids of the linked records.
+func (f fields) links(name string) []string {
+	var value []string
+	if raw, exist := f.lookup(name); exist {
+		_ = json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+// link reads a linked record field that should link to a single record.
+func (f fields) link(name string) string {
+	if links := f.links(name); len(links) > 0 {
+		return links[0]
+	}
+	return ""
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name))
+}
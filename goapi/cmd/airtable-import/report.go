@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"goapi/airtable"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// report is what the import found in the base, written before anything is imported.
+type report struct {
+	counts   map[airtable.Table]*count
+	problems []problem
+	notes    []string
+	unmapped map[airtable.Table][]string
+	// blocking is set for problems that stop the import from being written.
+	blocking bool
+}
+
+type count struct {
+	read     int
+	imported int
+	new      int
+}
+
+// problem is a record that is skipped or changed, like an orphan whose parent does not exist.
+type problem struct {
+	table   airtable.Table
+	id      string
+	message string
+}
+
+func newReport() *report {
+	counts := map[airtable.Table]*count{}
+	for _, table := range airtable.Tables {
+		counts[table] = &count{}
+	}
+	return &report{counts: counts, unmapped: map[airtable.Table][]string{}}
+}
+
+func (r *report) problem(table airtable.Table, id, format string, arguments ...interface{}) {
+	r.problems = append(r.problems, problem{table: table, id: id, message: fmt.Sprintf(format, arguments...)})
+}
+
+func (r *report) note(format string, arguments ...interface{}) {
+	r.notes = append(r.notes, fmt.Sprintf(format, arguments...))
+}
+
+func (r *report) write(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "Table\tRecords\tImported\tNew\tSkipped\t")
+	for _, name := range airtable.Tables {
+		c := r.counts[name]
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t\n", name, c.read, c.imported, c.new, c.read-c.imported)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	sections := []struct {
+		title string
+		lines []string
+	}{
+		{"Notes", r.notes},
+		{"Orphans and invalid records", r.problemLines()},
+		{"Unmapped fields", r.unmappedLines()},
+	}
+	for _, section := range sections {
+		if len(section.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", section.title)
+		for _, line := range section.lines {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	return nil
+}
+
+func (r *report) problemLines() []string {
+	lines := make([]string, 0, len(r.problems))
+	for _, p := range r.problems {
+		lines = append(lines, fmt.Sprintf("%s %s: %s", p.table, p.id, p.message))
+	}
+	return lines
+}
+
+func (r *report) unmappedLines() []string {
+	lines := []string{}
+	for _, table := range airtable.Tables {
+		if names := r.unmapped[table]; len(names) > 0 {
+			sort.Strings(names)
+			lines = append(lines, fmt.Sprintf("%s: %s", table, strings.Join(names, ", ")))
+		}
+	}
+	return lines
+}
@@ -3,10 +3,19 @@ package config
 import (
 	"github.com/kelseyhightower/envconfig"
 	"log"
+	"time"
 )
 
 type Config struct {
-	AirtableSecret string `split_words:"true"`
+	AirtableSecret  string        `split_words:"true"`
+	AirtableBaseUrl string        `split_words:"true" default:"https://api.airtable.com/v0/"`
+	AirtableBaseId  string        `split_words:"true" default:"appKpRGYhVdY3IspT"`
+	AirtableTimeout time.Duration `split_words:"true" default:"20s"`
+
+	AirtableCacheTtl  time.Duration `split_words:"true" default:"5m"`
+	AirtableCacheSize int           `split_words:"true" default:"1000"`
+	// AdminSecret guards the admin endpoints. They are turned off when it is empty.
+	AdminSecret string `split_words:"true" default:""`
 
 	PostgresHost     string `split_words:"true" default:"localhost"`
 	PostgresPort     int    `split_words:"true" default:"5432"`
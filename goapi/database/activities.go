@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"goapi/logger"
+	"goapi/models"
+	"time"
+)
+
+const sourceManual = "manual"
+
+type activityClient interface {
+	GetActivities(ctx context.Context, profileId string, from, to time.Time) ([]models.Activity, error)
+	GetActivity(ctx context.Context, id string) (models.Activity, error)
+	LogActivity(ctx context.Context, activity models.Activity) (models.Activity, error)
+	UpdateActivity(ctx context.Context, activity models.Activity) (models.Activity, error)
+	DeleteActivity(ctx context.Context, id string) error
+	SaveRecordedActivity(ctx context.Context, activity models.Activity, laps []models.Lap, samples []models.Sample) (models.Activity, error)
+	GetLaps(ctx context.Context, activityId string) ([]models.Lap, error)
+	GetSamples(ctx context.Context, activityId string) ([]models.Sample, error)
+}
+
+const activityColumns = `a.activity_uid, a.profile_uid, a.start_time, a.duration, COALESCE(a.moving_time, 0),
+	COALESCE(a.distance, 0), COALESCE(a.elevation_gain, 0), COALESCE(a.average_heart_rate, 0),
+	COALESCE(a.max_heart_rate, 0), COALESCE(a.rpe, 0), COALESCE(a.notes, ''),
+	COALESCE(a.workout_uid::text, ''), COALESCE(a.day_uid::text, ''), a.source`
+
+func activityDestinations(activity *models.Activity) []interface{} {
+	return []interface{}{
+		&activity.Id, &activity.ProfileId, &activity.StartTime, &activity.Duration, &activity.MovingTime,
+		&activity.Distance, &activity.ElevationGain, &activity.AverageHeartRate,
+		&activity.MaxHeartRate, &activity.Rpe, &activity.Notes,
+		&activity.WorkoutId, &activity.DayId, &activity.Source,
+	}
+}
+
+// GetActivities lists the activities of a profile that started within [from, to), latest first.
+// A zero from or to leaves that end of the period open.
+func (c *client) GetActivities(ctx context.Context, profileId string, from, to time.Time) ([]models.Activity, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT ` + activityColumns + `
+			FROM activity AS a
+			WHERE a.profile_uid = $1
+				AND ($2::timestamptz IS NULL OR a.start_time >= $2)
+				AND ($3::timestamptz IS NULL OR a.start_time < $3)
+			ORDER BY a.start_time DESC;`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, profileId, nullTime(from), nullTime(to))
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Activity{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	activities := []models.Activity{}
+	for rows.Next() {
+		var activity models.Activity
+		err = rows.Scan(activityDestinations(&activity)...)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Activity{}, err
+		}
+		activities = append(activities, activity)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Activity{}, err
+	}
+
+	return activities, nil
+}
+
+func (c *client) GetActivity(ctx context.Context, id string) (models.Activity, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement := `SELECT ` + activityColumns + ` FROM activity AS a WHERE a.activity_uid = $1;`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var activity models.Activity
+	err := row.Scan(activityDestinations(&activity)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Activity not found")
+			return models.Activity{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Activity{}, err
+	}
+
+	return activity, nil
+}
+
+// LogActivity saves a completed activity on the profile given by the activity.
+func (c *client) LogActivity(ctx context.Context, activity models.Activity) (models.Activity, error) {
+	activity.Id = createNewId()
+	activity.Source = sourceManual
+
+	err := insertActivity(ctx, c.db, activity)
+	if err != nil {
+		return models.Activity{}, err
+	}
+
+	return c.GetActivity(ctx, activity.Id)
+}
+
+// SaveRecordedActivity saves an activity imported from a file, together with its laps and samples.
+func (c *client) SaveRecordedActivity(ctx context.Context, activity models.Activity, laps []models.Lap, samples []models.Sample) (models.Activity, error) {
+	log := logger.FromContext(ctx)
+
+	activity.Id = createNewId()
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		err := insertActivity(ctx, tx, activity)
+		if err != nil {
+			return err
+		}
+
+		for _, lap := range laps {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO activity_lap (activity_uid, "order", start_time, duration, distance, average_heart_rate, max_heart_rate)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				activity.Id, lap.Order, lap.StartTime, lap.Duration, lap.Distance,
+				nullInt(lap.AverageHeartRate), nullInt(lap.MaxHeartRate))
+			if err != nil {
+				log.WithError(err).Error("error during insert to db")
+				return err
+			}
+		}
+
+		// A recording has a sample every second or so, which is far quicker to copy than to insert one by one.
+		statement, err := tx.PrepareContext(ctx, pq.CopyIn("activity_sample",
+			"activity_uid", "index", "time", "latitude", "longitude", "elevation", "distance", "heart_rate", "cadence", "power"))
+		if err != nil {
+			log.WithError(err).Error("error while preparing copy to db")
+			return err
+		}
+		for index, sample := range samples {
+			var latitude, longitude, elevation interface{}
+			if sample.HasPosition {
+				latitude, longitude = sample.Latitude, sample.Longitude
+			}
+			if sample.HasElevation {
+				elevation = sample.Elevation
+			}
+			_, err = statement.ExecContext(ctx, activity.Id, index, sample.Time, latitude, longitude, elevation,
+				sample.Distance, nullInt(sample.HeartRate), nullInt(sample.Cadence), nullInt(sample.Power))
+			if err != nil {
+				log.WithError(err).Error("error during copy to db")
+				_ = statement.Close()
+				return err
+			}
+		}
+		_, err = statement.ExecContext(ctx)
+		if err != nil {
+			log.WithError(err).Error("error during copy to db")
+			_ = statement.Close()
+			return err
+		}
+		return statement.Close()
+	})
+	if err != nil {
+		return models.Activity{}, err
+	}
+
+	return c.GetActivity(ctx, activity.Id)
+}
+
+// execer is either the database or a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertActivity(ctx context.Context, db execer, activity models.Activity) error {
+	sqlStatement :=
+		`INSERT INTO activity (activity_uid, profile_uid, start_time, duration, moving_time, distance, elevation_gain,
+				average_heart_rate, max_heart_rate, rpe, notes, workout_uid, day_uid, source)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	_, err := db.ExecContext(ctx, sqlStatement, activity.Id, activity.ProfileId, activity.StartTime, activity.Duration,
+		nullInt(activity.MovingTime), nullInt(activity.Distance), nullInt(activity.ElevationGain),
+		nullInt(activity.AverageHeartRate), nullInt(activity.MaxHeartRate), nullInt(activity.Rpe),
+		nullString(activity.Notes), nullString(activity.WorkoutId), nullString(activity.DayId), activity.Source)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("error during insert to db")
+		return err
+	}
+	return nil
+}
+
+func (c *client) UpdateActivity(ctx context.Context, activity models.Activity) (models.Activity, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`UPDATE activity SET start_time = $2, duration = $3, distance = $4, average_heart_rate = $5, rpe = $6,
+				notes = $7, workout_uid = $8, day_uid = $9
+			WHERE activity_uid = $1`
+
+	result, err := c.db.ExecContext(ctx, sqlStatement, activity.Id, activity.StartTime, activity.Duration,
+		nullInt(activity.Distance), nullInt(activity.AverageHeartRate), nullInt(activity.Rpe),
+		nullString(activity.Notes), nullString(activity.WorkoutId), nullString(activity.DayId))
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return models.Activity{}, err
+	}
+	err = ensureRowsAffected(ctx, result)
+	if err != nil {
+		return models.Activity{}, err
+	}
+
+	return c.GetActivity(ctx, activity.Id)
+}
+
+func (c *client) DeleteActivity(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	_, err := c.db.ExecContext(ctx, `DELETE FROM activity WHERE activity_uid = $1`, id)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+
+	return nil
+}
+
+func (c *client) GetLaps(ctx context.Context, activityId string) ([]models.Lap, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT "order", start_time, duration, distance, COALESCE(average_heart_rate, 0), COALESCE(max_heart_rate, 0)
+			FROM activity_lap WHERE activity_uid = $1
+			ORDER BY "order";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, activityId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Lap{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	laps := []models.Lap{}
+	for rows.Next() {
+		var lap models.Lap
+		err = rows.Scan(&lap.Order, &lap.StartTime, &lap.Duration, &lap.Distance, &lap.AverageHeartRate, &lap.MaxHeartRate)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Lap{}, err
+		}
+		laps = append(laps, lap)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Lap{}, err
+	}
+
+	return laps, nil
+}
+
+func (c *client) GetSamples(ctx context.Context, activityId string) ([]models.Sample, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT time, latitude, longitude, elevation, distance,
+				COALESCE(heart_rate, 0), COALESCE(cadence, 0), COALESCE(power, 0)
+			FROM activity_sample WHERE activity_uid = $1
+			ORDER BY "index";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, activityId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Sample{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	samples := []models.Sample{}
+	for rows.Next() {
+		var sample models.Sample
+		var latitude, longitude, elevation sql.NullFloat64
+		err = rows.Scan(&sample.Time, &latitude, &longitude, &elevation, &sample.Distance,
+			&sample.HeartRate, &sample.Cadence, &sample.Power)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Sample{}, err
+		}
+		sample.HasPosition = latitude.Valid && longitude.Valid
+		sample.Latitude, sample.Longitude = latitude.Float64, longitude.Float64
+		sample.HasElevation, sample.Elevation = elevation.Valid, elevation.Float64
+		samples = append(samples, sample)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Sample{}, err
+	}
+
+	return samples, nil
+}
@@ -11,6 +11,7 @@ import (
 	_ "github.com/lib/pq"
 	"goapi/config"
 	"goapi/logger"
+	"time"
 )
 
 type Client interface {
@@ -18,6 +19,10 @@ type Client interface {
 	intensityClient
 	workoutClient
 	profileClient
+	planClient
+	activityClient
+	scheduleClient
+	importClient
 }
 
 type client struct {
@@ -73,3 +78,54 @@ func (c *client) Close() error {
 func createNewId() string {
 	return uuid.Must(uuid.NewV4()).String()
 }
+
+// nullString maps the empty string to NULL.
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullInt maps zero to NULL.
+func nullInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(value time.Time) interface{} {
+	if value.IsZero() {
+		return nil
+	}
+	return value
+}
+
+// inTransaction runs fn inside a transaction, which is committed if fn succeeds and rolled back otherwise.
+func (c *client) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	log := logger.FromContext(ctx)
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.WithError(err).Error("Error while starting transaction")
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			log.WithError(rollbackErr).Error("Error while rolling back transaction")
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.WithError(err).Error("Error while committing transaction")
+		return err
+	}
+	return nil
+}
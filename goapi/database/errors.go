@@ -1,9 +1,32 @@
 package database
 
-import "github.com/pkg/errors"
+import (
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"goapi/logger"
+)
 
 type EntityNotFound error
 
 func newEntityNotFoundError(err error) EntityNotFound {
 	return errors.WithMessage(err, "The entity was not found")
 }
+
+// IsNotFound tells if an error is caused by an entity that was not found.
+func IsNotFound(err error) bool {
+	return errors.Cause(err) == sql.ErrNoRows
+}
+
+// ensureRowsAffected returns an EntityNotFound error if the statement did not change any rows.
+func ensureRowsAffected(ctx context.Context, result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Error while reading affected rows")
+		return err
+	}
+	if affected == 0 {
+		return newEntityNotFoundError(sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"goapi/logger"
+	"goapi/models"
+)
+
+type importClient interface {
+	GetAirtableIds(ctx context.Context) (map[string]string, error)
+	ImportAirtable(ctx context.Context, imported AirtableImport) error
+}
+
+// AirtableImport is the content of the Airtable base, with the ids it gets in the database. Every row is
+// inserted, or updated when it was imported before.
+type AirtableImport struct {
+	Ids         []AirtableId
+	Profiles    []models.Profile
+	Records     []models.Record
+	Intensities []models.Intensity
+	Workouts    []ImportedWorkout
+	Plans       []models.Plan
+	Weeks       []models.Week
+	Days        []models.Day
+	DayWorkouts []DayWorkout
+}
+
+// AirtableId maps the id of an Airtable record to the id of the row it becomes.
+type AirtableId struct {
+	AirtableId string
+	Table      string
+	Id         string
+}
+
+// ImportedWorkout is a workout with all its parts, which replace the parts it has.
+type ImportedWorkout struct {
+	models.Workout
+	Parts []models.WorkoutPart
+}
+
+// DayWorkout places a workout on a plan day.
+type DayWorkout struct {
+	DayId     string
+	WorkoutId string
+	Order     int
+}
+
+// GetAirtableIds maps the ids of the Airtable records imported before to the ids of their rows.
+func (c *client) GetAirtableIds(ctx context.Context) (map[string]string, error) {
+	log := logger.FromContext(ctx)
+
+	rows, err := c.db.QueryContext(ctx, `SELECT airtable_id, uid FROM airtable_import`)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	ids := map[string]string{}
+	for rows.Next() {
+		var airtableId, id string
+		err := rows.Scan(&airtableId, &id)
+		if err != nil {
+			log.WithError(err).Error("Error scanning row")
+			return nil, err
+		}
+		ids[airtableId] = id
+	}
+	return ids, rows.Err()
+}
+
+// ImportAirtable writes the content of the Airtable base in one transaction. The weeks of imported plans and
+// the days of imported weeks that were imported before but are no longer in the import are removed. Weeks and
+// days added in the app are kept. The parts of imported workouts and the workouts of imported days are replaced
+// by the imported ones.
+func (c *client) ImportAirtable(ctx context.Context, imported AirtableImport) error {
+	log := logger.FromContext(ctx)
+
+	return c.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, step := range []func(context.Context, *sql.Tx, AirtableImport) error{
+			importProfiles, importRecords, importIntensities, importWorkouts, importPlans, importAirtableIds,
+		} {
+			err := step(ctx, tx, imported)
+			if err != nil {
+				log.WithError(err).Error("error during import to db")
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// importProfiles creates the profiles that are new with a copy of the default intensities, like profiles
+// created at login, and updates the names and VDOT of the profiles that were imported before.
+func importProfiles(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, profile := range imported.Profiles {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM profile WHERE profile_uid = $1)`, profile.Id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO profile (profile_uid, first_name, last_name, vdot) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (profile_uid) DO UPDATE
+				SET first_name = EXCLUDED.first_name, last_name = EXCLUDED.last_name, vdot = EXCLUDED.vdot`,
+			profile.Id, profile.FirstName, profile.LastName, nullInt(profile.Vdot))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			err = copyDefaultIntensities(ctx, tx, profile.Id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func importRecords(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, record := range imported.Records {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO record (record_uid, profile_uid, race, distance, duration, date)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (record_uid) DO UPDATE
+				SET profile_uid = EXCLUDED.profile_uid, race = EXCLUDED.race, distance = EXCLUDED.distance,
+					duration = EXCLUDED.duration, date = EXCLUDED.date`,
+			record.Id, record.ProfileId, record.Race, nullInt(record.Distance), nullInt(record.Duration),
+			nullTime(record.Date))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importIntensities adds new intensities after the intensities their creator has, and keeps the order of
+// intensities that were imported before.
+func importIntensities(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, intensity := range imported.Intensities {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO intensity (intensity_uid, created_by_uid, name, description, coefficient, "order")
+				VALUES ($1, $2, $3, $4, $5,
+					(SELECT COALESCE(MAX("order") + 1, 0) FROM intensity WHERE created_by_uid = $2))
+				ON CONFLICT (intensity_uid) DO UPDATE
+				SET name = EXCLUDED.name, description = EXCLUDED.description, coefficient = EXCLUDED.coefficient`,
+			intensity.Id, intensity.CreatedBy, intensity.Name, nullString(intensity.Description), intensity.Coefficient)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importWorkouts(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, workout := range imported.Workouts {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO workout (workout_uid, created_by_uid, name, description) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (workout_uid) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description`,
+			workout.Id, workout.CreatedBy, workout.Name, nullString(workout.Description))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM workout_parts WHERE workout_uid = $1`, workout.Id)
+		if err != nil {
+			return err
+		}
+		// A part that moved to another workout in Airtable is still stored with the workout it had.
+		for _, part := range workout.Parts {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO workout_parts (part_uid, workout_uid, intensity_uid, created_by_uid, "order", distance, metric)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)
+					ON CONFLICT (part_uid) DO UPDATE
+					SET workout_uid = EXCLUDED.workout_uid, intensity_uid = EXCLUDED.intensity_uid,
+						created_by_uid = EXCLUDED.created_by_uid, "order" = EXCLUDED."order",
+						distance = EXCLUDED.distance, metric = EXCLUDED.metric, parent_uid = NULL, repeat = NULL`,
+				part.Id, workout.Id, part.Intensity.Id, workout.CreatedBy, part.Order, part.Distance, part.Metric)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func importPlans(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, plan := range imported.Plans {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO plan (plan_uid, created_by_uid, name, description) VALUES ($1, $2, $3, $4)
+				ON CONFLICT (plan_uid) DO UPDATE
+				SET name = EXCLUDED.name, description = EXCLUDED.description, revision = plan.revision + 1`,
+			plan.Id, plan.CreatedBy, plan.Name, nullString(plan.Description))
+		if err != nil {
+			return err
+		}
+
+		weekIds := []string{}
+		for _, week := range imported.Weeks {
+			if week.PlanId == plan.Id {
+				weekIds = append(weekIds, week.Id)
+			}
+		}
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM plan_week WHERE plan_uid = $1 AND NOT (week_uid = ANY($2::uuid[]))
+				AND week_uid IN (SELECT uid FROM airtable_import WHERE table_name = 'Week')`,
+			plan.Id, pq.Array(weekIds))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, week := range imported.Weeks {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO plan_week (week_uid, plan_uid, "order") VALUES ($1, $2, $3)
+				ON CONFLICT (week_uid) DO UPDATE SET plan_uid = EXCLUDED.plan_uid, "order" = EXCLUDED."order"`,
+			week.Id, week.PlanId, week.Order)
+		if err != nil {
+			return err
+		}
+
+		dayIds := []string{}
+		for _, day := range imported.Days {
+			if day.WeekId == week.Id {
+				dayIds = append(dayIds, day.Id)
+			}
+		}
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM plan_day WHERE week_uid = $1 AND NOT (day_uid = ANY($2::uuid[]))
+				AND day_uid IN (SELECT uid FROM airtable_import WHERE table_name = 'Day')`,
+			week.Id, pq.Array(dayIds))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, day := range imported.Days {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO plan_day (day_uid, week_uid, day) VALUES ($1, $2, $3)
+				ON CONFLICT (day_uid) DO UPDATE SET week_uid = EXCLUDED.week_uid, day = EXCLUDED.day`,
+			day.Id, day.WeekId, day.Day)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM plan_day_workout WHERE day_uid = $1`, day.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dayWorkout := range imported.DayWorkouts {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO plan_day_workout (day_uid, workout_uid, "order") VALUES ($1, $2, $3)`,
+			dayWorkout.DayId, dayWorkout.WorkoutId, dayWorkout.Order)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importAirtableIds(ctx context.Context, tx *sql.Tx, imported AirtableImport) error {
+	for _, id := range imported.Ids {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO airtable_import (airtable_id, table_name, uid) VALUES ($1, $2, $3)
+				ON CONFLICT (airtable_id) DO UPDATE SET uid = EXCLUDED.uid, imported_at = NOW()`,
+			id.AirtableId, id.Table, id.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
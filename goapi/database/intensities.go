@@ -2,20 +2,97 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"github.com/pkg/errors"
 	"goapi/logger"
 	"goapi/models"
 )
 
+var (
+	ErrIntensityInUse        = errors.New("the intensity is used by workouts, and can not be deleted")
+	ErrInvalidIntensityOrder = errors.New("the intensities must be listed exactly once each")
+)
+
+// intensityTargetColumns selects the targets of the intensity aliased i, with 0 for the targets that are not set.
+const intensityTargetColumns = `COALESCE(i.heart_rate_max_min, 0), COALESCE(i.heart_rate_max_max, 0),
+	COALESCE(i.heart_rate_reserve_min, 0), COALESCE(i.heart_rate_reserve_max, 0),
+	COALESCE(i.vdot_min, 0), COALESCE(i.vdot_max, 0),
+	COALESCE(i.rpe_min, 0), COALESCE(i.rpe_max, 0),
+	COALESCE(i.power_min, 0), COALESCE(i.power_max, 0)`
+
+// intensityTargetDestinations are the scan destinations of intensityTargetColumns.
+func intensityTargetDestinations(targets *models.IntensityTargets) []interface{} {
+	return []interface{}{
+		&targets.HeartRateMax.Min, &targets.HeartRateMax.Max,
+		&targets.HeartRateReserve.Min, &targets.HeartRateReserve.Max,
+		&targets.Vdot.Min, &targets.Vdot.Max,
+		&targets.Rpe.Min, &targets.Rpe.Max,
+		&targets.Power.Min, &targets.Power.Max,
+	}
+}
+
+// nullRange maps a range that is not set to NULL values.
+func nullRange(r models.Range) (interface{}, interface{}) {
+	if !r.IsSet() {
+		return nil, nil
+	}
+	return r.Min, r.Max
+}
+
+// targetArguments lists the targets in the order of the target columns.
+func targetArguments(targets models.IntensityTargets) []interface{} {
+	var arguments []interface{}
+	for _, r := range []models.Range{targets.HeartRateMax, targets.HeartRateReserve, targets.Vdot, targets.Rpe, targets.Power} {
+		min, max := nullRange(r)
+		arguments = append(arguments, min, max)
+	}
+	return arguments
+}
+
 type intensityClient interface {
-	GetIntensities(ctx context.Context) ([]models.Intensity, error)
+	GetIntensity(ctx context.Context, id string) (models.Intensity, error)
+	GetIntensitiesForProfile(ctx context.Context, profileId string) ([]models.Intensity, error)
+	CreateIntensity(ctx context.Context, profileId, name, description string, coefficient float64, targets models.IntensityTargets) (models.Intensity, error)
+	UpdateIntensity(ctx context.Context, id, name, description string, coefficient float64, targets models.IntensityTargets) (models.Intensity, error)
+	DeleteIntensity(ctx context.Context, id string) error
+	ReorderIntensities(ctx context.Context, profileId string, ids []string) ([]models.Intensity, error)
 }
 
-func (c *client) GetIntensities(ctx context.Context) ([]models.Intensity, error) {
+func (c *client) GetIntensity(ctx context.Context, id string) (models.Intensity, error) {
 	log := logger.FromContext(ctx)
 
-	sqlStatement := `SELECT intensity_uid, name, description, coefficient FROM Intensity;`
+	sqlStatement :=
+		`SELECT i.intensity_uid, i.name, COALESCE(i.description, ''), i.coefficient, i."order", i.created_by_uid, ` +
+			intensityTargetColumns + ` FROM intensity AS i
+			WHERE i.intensity_uid = $1;`
 
-	rows, err := c.db.Query(sqlStatement)
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var intensity models.Intensity
+	destinations := []interface{}{&intensity.Id, &intensity.Name, &intensity.Description, &intensity.Coefficient, &intensity.Order, &intensity.CreatedBy}
+	err := row.Scan(append(destinations, intensityTargetDestinations(&intensity.Targets)...)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Intensity not found")
+			return models.Intensity{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Intensity{}, err
+	}
+
+	return intensity, nil
+}
+
+func (c *client) GetIntensitiesForProfile(ctx context.Context, profileId string) ([]models.Intensity, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT i.intensity_uid, i.name, COALESCE(i.description, ''), i.coefficient, i."order", i.created_by_uid, ` +
+			intensityTargetColumns + ` FROM intensity AS i
+			WHERE i.created_by_uid = $1
+			ORDER BY i."order";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, profileId)
 	if err != nil {
 		log.WithError(err).Error("Error querying db")
 		return []models.Intensity{}, err
@@ -30,7 +107,8 @@ func (c *client) GetIntensities(ctx context.Context) ([]models.Intensity, error)
 	var intensities []models.Intensity
 	for rows.Next() {
 		var intensity models.Intensity
-		err = rows.Scan(&intensity.Id, &intensity.Name, &intensity.Description, &intensity.Coefficient)
+		destinations := []interface{}{&intensity.Id, &intensity.Name, &intensity.Description, &intensity.Coefficient, &intensity.Order, &intensity.CreatedBy}
+		err = rows.Scan(append(destinations, intensityTargetDestinations(&intensity.Targets)...)...)
 		if err != nil {
 			log.WithError(err).Error("Error while parsing db row")
 			return []models.Intensity{}, err
@@ -47,3 +125,128 @@ func (c *client) GetIntensities(ctx context.Context) ([]models.Intensity, error)
 	return intensities, nil
 }
 
+// CreateIntensity adds an intensity to the end of the profile's intensities.
+func (c *client) CreateIntensity(ctx context.Context, profileId, name, description string, coefficient float64, targets models.IntensityTargets) (models.Intensity, error) {
+	log := logger.FromContext(ctx)
+
+	id := createNewId()
+
+	sqlStatement :=
+		`INSERT INTO intensity (intensity_uid, created_by_uid, name, description, coefficient, "order",
+				heart_rate_max_min, heart_rate_max_max, heart_rate_reserve_min, heart_rate_reserve_max,
+				vdot_min, vdot_max, rpe_min, rpe_max, power_min, power_max)
+			SELECT $1, $2, $3, $4, $5, COUNT(*), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+				FROM intensity WHERE created_by_uid = $2`
+
+	arguments := []interface{}{id, profileId, name, nullString(description), coefficient}
+	_, err := c.db.ExecContext(ctx, sqlStatement, append(arguments, targetArguments(targets)...)...)
+	if err != nil {
+		log.WithError(err).Error("error during insert to db")
+		return models.Intensity{}, err
+	}
+
+	return c.GetIntensity(ctx, id)
+}
+
+func (c *client) UpdateIntensity(ctx context.Context, id, name, description string, coefficient float64, targets models.IntensityTargets) (models.Intensity, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`UPDATE intensity SET name = $2, description = $3, coefficient = $4,
+			heart_rate_max_min = $5, heart_rate_max_max = $6, heart_rate_reserve_min = $7, heart_rate_reserve_max = $8,
+			vdot_min = $9, vdot_max = $10, rpe_min = $11, rpe_max = $12, power_min = $13, power_max = $14
+			WHERE intensity_uid = $1`
+
+	arguments := []interface{}{id, name, nullString(description), coefficient}
+	result, err := c.db.ExecContext(ctx, sqlStatement, append(arguments, targetArguments(targets)...)...)
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return models.Intensity{}, err
+	}
+	err = ensureRowsAffected(ctx, result)
+	if err != nil {
+		return models.Intensity{}, err
+	}
+
+	return c.GetIntensity(ctx, id)
+}
+
+// DeleteIntensity deletes an intensity and closes the gap it leaves in the order of the profile's intensities.
+// Intensities that are used by workout parts can not be deleted.
+func (c *client) DeleteIntensity(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	intensity, err := c.GetIntensity(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.inTransaction(ctx, func(tx *sql.Tx) error {
+		var inUse bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM workout_parts WHERE intensity_uid = $1)`, id).Scan(&inUse)
+		if err != nil {
+			log.WithError(err).Error("Error querying db")
+			return err
+		}
+		if inUse {
+			return ErrIntensityInUse
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM intensity WHERE intensity_uid = $1`, id)
+		if err != nil {
+			log.WithError(err).Error("error during delete from db")
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE intensity SET "order" = "order" - 1 WHERE created_by_uid = $1 AND "order" > $2`,
+			intensity.CreatedBy, intensity.Order)
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+		return nil
+	})
+}
+
+// ReorderIntensities orders the profile's intensities as the ids are listed. Every intensity of the profile
+// must be listed exactly once.
+func (c *client) ReorderIntensities(ctx context.Context, profileId string, ids []string) ([]models.Intensity, error) {
+	log := logger.FromContext(ctx)
+
+	intensities, err := c.GetIntensitiesForProfile(ctx, profileId)
+	if err != nil {
+		return []models.Intensity{}, err
+	}
+
+	existing := make(map[string]bool)
+	for _, intensity := range intensities {
+		existing[intensity.Id] = true
+	}
+	if len(ids) != len(existing) {
+		return []models.Intensity{}, ErrInvalidIntensityOrder
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return []models.Intensity{}, ErrInvalidIntensityOrder
+		}
+		delete(existing, id)
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		for order, id := range ids {
+			_, err := tx.ExecContext(ctx, `UPDATE intensity SET "order" = $2 WHERE intensity_uid = $1`, id, order)
+			if err != nil {
+				log.WithError(err).Error("error during update of db")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return []models.Intensity{}, err
+	}
+
+	return c.GetIntensitiesForProfile(ctx, profileId)
+}
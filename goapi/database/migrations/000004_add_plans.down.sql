@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS plan_day_workout;
+DROP TABLE IF EXISTS plan_day;
+DROP TABLE IF EXISTS plan_week;
+DROP TABLE IF EXISTS plan;
+
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS plan (
+    plan_uid UUID NOT NULL PRIMARY KEY,
+    created_by_uid UUID NOT NULL REFERENCES profile(profile_uid),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS plan_week (
+    week_uid UUID NOT NULL PRIMARY KEY,
+    plan_uid UUID NOT NULL REFERENCES plan(plan_uid) ON DELETE CASCADE,
+    "order" INT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    UNIQUE (plan_uid, "order") DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE TABLE IF NOT EXISTS plan_day (
+    day_uid UUID NOT NULL PRIMARY KEY,
+    week_uid UUID NOT NULL REFERENCES plan_week(week_uid) ON DELETE CASCADE,
+    day INT NOT NULL CHECK (day >= 0 AND day < 7),
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    UNIQUE (week_uid, day)
+);
+
+CREATE TABLE IF NOT EXISTS plan_day_workout (
+    day_uid UUID NOT NULL REFERENCES plan_day(day_uid) ON DELETE CASCADE,
+    workout_uid UUID NOT NULL REFERENCES workout(workout_uid) ON DELETE CASCADE,
+    "order" INT NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (day_uid, "order")
+);
+
+COMMIT;
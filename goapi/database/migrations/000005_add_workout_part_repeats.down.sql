@@ -0,0 +1,14 @@
+BEGIN;
+
+DELETE FROM workout_parts WHERE parent_uid IS NOT NULL OR repeat IS NOT NULL;
+
+DROP INDEX IF EXISTS workout_parts_order_idx;
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_intensity_or_repeat;
+ALTER TABLE workout_parts ALTER COLUMN intensity_uid SET NOT NULL;
+ALTER TABLE workout_parts DROP COLUMN repeat;
+ALTER TABLE workout_parts DROP COLUMN parent_uid;
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_pkey;
+ALTER TABLE workout_parts ADD PRIMARY KEY (workout_uid, "order");
+ALTER TABLE workout_parts DROP COLUMN part_uid;
+
+COMMIT;
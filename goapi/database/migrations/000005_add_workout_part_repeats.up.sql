@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE workout_parts ADD COLUMN part_uid UUID NOT NULL DEFAULT uuid_generate_v4();
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_pkey;
+ALTER TABLE workout_parts ADD PRIMARY KEY (part_uid);
+
+-- A part with a repeat count is a block, whose child parts are run repeat times.
+ALTER TABLE workout_parts ADD COLUMN parent_uid UUID REFERENCES workout_parts(part_uid) ON DELETE CASCADE;
+ALTER TABLE workout_parts ADD COLUMN repeat INT CHECK (repeat > 0);
+ALTER TABLE workout_parts ALTER COLUMN intensity_uid DROP NOT NULL;
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_intensity_or_repeat
+    CHECK ((repeat IS NULL) <> (intensity_uid IS NULL));
+
+-- The order is unique among the parts sharing a parent. Top level parts use the workout as their parent.
+CREATE UNIQUE INDEX workout_parts_order_idx ON workout_parts (workout_uid, COALESCE(parent_uid, workout_uid), "order");
+
+COMMIT;
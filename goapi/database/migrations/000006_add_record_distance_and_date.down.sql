@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE record DROP COLUMN date;
+ALTER TABLE record DROP COLUMN distance;
+
+COMMIT;
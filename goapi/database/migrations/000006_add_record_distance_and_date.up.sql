@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE record ADD COLUMN distance INT CHECK (distance > 0);
+ALTER TABLE record ADD COLUMN date DATE;
+
+UPDATE record SET distance = CASE lower(race)
+    WHEN '1500m' THEN 1500
+    WHEN 'mile' THEN 1609
+    WHEN '3k' THEN 3000
+    WHEN '5k' THEN 5000
+    WHEN '10k' THEN 10000
+    WHEN 'half-marathon' THEN 21097
+    WHEN 'marathon' THEN 42195
+END;
+
+COMMIT;
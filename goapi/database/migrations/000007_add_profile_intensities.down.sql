@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS default_intensity;
+
+ALTER TABLE intensity DROP CONSTRAINT IF EXISTS intensity_order_key;
+ALTER TABLE intensity DROP COLUMN IF EXISTS "order";
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+ALTER TABLE intensity ADD COLUMN "order" INT;
+
+UPDATE intensity SET "order" = ordered.row_number - 1
+FROM (
+    SELECT intensity_uid, ROW_NUMBER() OVER (PARTITION BY created_by_uid ORDER BY coefficient, created_at) AS row_number
+    FROM intensity
+) AS ordered
+WHERE intensity.intensity_uid = ordered.intensity_uid;
+
+ALTER TABLE intensity ALTER COLUMN "order" SET NOT NULL;
+ALTER TABLE intensity ADD CONSTRAINT intensity_order_key UNIQUE (created_by_uid, "order") DEFERRABLE INITIALLY DEFERRED;
+
+CREATE TABLE IF NOT EXISTS default_intensity (
+    "order" INT NOT NULL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    coefficient FLOAT NOT NULL
+);
+
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (0, 'Easy', '65%-79% of max hearth rate, or 59%-74% of VDOT.', 0.2);
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (1, 'Marathon', '80%-89% of max hearth rate, or 75%-84% of VDOT.', 0.4);
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (2, 'Threshold', 'Lactate threshold. 88%-92% of max hearth rate, or 83%-88% of VDOT.', 0.6);
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (3, '10k', '10k race pace. Between threshold and interval speed.', 0.8);
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (4, 'Interval', '97.5-100% of max heart rate, or 95%-100% of VDOT.', 1.0);
+INSERT INTO default_intensity ("order", name, description, coefficient) VALUES (5, 'Repetition', '65%-79% of max hearth rate, or 59%-74% of VDOT.', 1.5);
+
+COMMIT;
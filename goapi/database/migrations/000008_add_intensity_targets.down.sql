@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE default_intensity
+    DROP COLUMN heart_rate_max_min, DROP COLUMN heart_rate_max_max,
+    DROP COLUMN heart_rate_reserve_min, DROP COLUMN heart_rate_reserve_max,
+    DROP COLUMN vdot_min, DROP COLUMN vdot_max,
+    DROP COLUMN rpe_min, DROP COLUMN rpe_max,
+    DROP COLUMN power_min, DROP COLUMN power_max;
+
+ALTER TABLE intensity
+    DROP COLUMN heart_rate_max_min, DROP COLUMN heart_rate_max_max,
+    DROP COLUMN heart_rate_reserve_min, DROP COLUMN heart_rate_reserve_max,
+    DROP COLUMN vdot_min, DROP COLUMN vdot_max,
+    DROP COLUMN rpe_min, DROP COLUMN rpe_max,
+    DROP COLUMN power_min, DROP COLUMN power_max;
+
+ALTER TABLE profile DROP COLUMN threshold_power;
+ALTER TABLE profile DROP COLUMN resting_heart_rate;
+ALTER TABLE profile DROP COLUMN max_heart_rate;
+
+COMMIT;
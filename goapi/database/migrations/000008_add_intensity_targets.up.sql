@@ -0,0 +1,64 @@
+BEGIN;
+
+ALTER TABLE profile ADD COLUMN max_heart_rate INT CHECK (max_heart_rate > 0);
+ALTER TABLE profile ADD COLUMN resting_heart_rate INT CHECK (resting_heart_rate > 0);
+ALTER TABLE profile ADD COLUMN threshold_power INT CHECK (threshold_power > 0);
+
+-- The targets are percentages, except RPE which is on the 1-10 scale. The VDOT band is the share of VO2max
+-- the pace costs, and power is relative to the threshold power.
+ALTER TABLE intensity ADD COLUMN heart_rate_max_min FLOAT;
+ALTER TABLE intensity ADD COLUMN heart_rate_max_max FLOAT;
+ALTER TABLE intensity ADD COLUMN heart_rate_reserve_min FLOAT;
+ALTER TABLE intensity ADD COLUMN heart_rate_reserve_max FLOAT;
+ALTER TABLE intensity ADD COLUMN vdot_min FLOAT;
+ALTER TABLE intensity ADD COLUMN vdot_max FLOAT;
+ALTER TABLE intensity ADD COLUMN rpe_min FLOAT;
+ALTER TABLE intensity ADD COLUMN rpe_max FLOAT;
+ALTER TABLE intensity ADD COLUMN power_min FLOAT;
+ALTER TABLE intensity ADD COLUMN power_max FLOAT;
+
+ALTER TABLE default_intensity ADD COLUMN heart_rate_max_min FLOAT;
+ALTER TABLE default_intensity ADD COLUMN heart_rate_max_max FLOAT;
+ALTER TABLE default_intensity ADD COLUMN heart_rate_reserve_min FLOAT;
+ALTER TABLE default_intensity ADD COLUMN heart_rate_reserve_max FLOAT;
+ALTER TABLE default_intensity ADD COLUMN vdot_min FLOAT;
+ALTER TABLE default_intensity ADD COLUMN vdot_max FLOAT;
+ALTER TABLE default_intensity ADD COLUMN rpe_min FLOAT;
+ALTER TABLE default_intensity ADD COLUMN rpe_max FLOAT;
+ALTER TABLE default_intensity ADD COLUMN power_min FLOAT;
+ALTER TABLE default_intensity ADD COLUMN power_max FLOAT;
+
+UPDATE default_intensity SET
+    heart_rate_max_min = 65, heart_rate_max_max = 79, heart_rate_reserve_min = 59, heart_rate_reserve_max = 74,
+    vdot_min = 62, vdot_max = 70, rpe_min = 2, rpe_max = 3, power_min = 65, power_max = 80
+    WHERE name = 'Easy';
+UPDATE default_intensity SET
+    heart_rate_max_min = 80, heart_rate_max_max = 89, heart_rate_reserve_min = 75, heart_rate_reserve_max = 84,
+    vdot_min = 78, vdot_max = 84, rpe_min = 4, rpe_max = 5, power_min = 80, power_max = 90
+    WHERE name = 'Marathon';
+UPDATE default_intensity SET
+    heart_rate_max_min = 88, heart_rate_max_max = 92, heart_rate_reserve_min = 83, heart_rate_reserve_max = 88,
+    vdot_min = 86, vdot_max = 90, rpe_min = 6, rpe_max = 7, power_min = 90, power_max = 100
+    WHERE name = 'Threshold';
+UPDATE default_intensity SET
+    heart_rate_max_min = 92, heart_rate_max_max = 96, heart_rate_reserve_min = 90, heart_rate_reserve_max = 94,
+    vdot_min = 90, vdot_max = 94, rpe_min = 7, rpe_max = 8, power_min = 100, power_max = 105
+    WHERE name = '10k';
+UPDATE default_intensity SET
+    heart_rate_max_min = 97.5, heart_rate_max_max = 100, heart_rate_reserve_min = 95, heart_rate_reserve_max = 100,
+    vdot_min = 95, vdot_max = 100, rpe_min = 8, rpe_max = 9, power_min = 105, power_max = 115
+    WHERE name = 'Interval';
+UPDATE default_intensity SET
+    vdot_min = 105, vdot_max = 110, rpe_min = 9, rpe_max = 10, power_min = 115, power_max = 130
+    WHERE name = 'Repetition';
+
+-- Intensities copied from the defaults get the default targets, except the VDOT band. The target pace prefers
+-- the band over the estimate from the coefficient, so the paces of existing intensities would change.
+UPDATE intensity SET
+    heart_rate_max_min = d.heart_rate_max_min, heart_rate_max_max = d.heart_rate_max_max,
+    heart_rate_reserve_min = d.heart_rate_reserve_min, heart_rate_reserve_max = d.heart_rate_reserve_max,
+    rpe_min = d.rpe_min, rpe_max = d.rpe_max, power_min = d.power_min, power_max = d.power_max
+    FROM default_intensity AS d
+    WHERE lower(intensity.name) = lower(d.name);
+
+COMMIT;
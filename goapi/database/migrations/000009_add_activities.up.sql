@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS activity (
+    activity_uid UUID NOT NULL PRIMARY KEY,
+    profile_uid UUID NOT NULL REFERENCES profile(profile_uid) ON DELETE CASCADE,
+    start_time timestamptz NOT NULL,
+    duration INT NOT NULL CHECK (duration > 0),
+    distance INT CHECK (distance > 0),
+    average_heart_rate INT CHECK (average_heart_rate > 0),
+    rpe INT CHECK (rpe BETWEEN 1 AND 10),
+    notes TEXT,
+    workout_uid UUID REFERENCES workout(workout_uid) ON DELETE SET NULL,
+    day_uid UUID REFERENCES plan_day(day_uid) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS activity_start_time_idx ON activity (profile_uid, start_time);
+
+COMMIT;
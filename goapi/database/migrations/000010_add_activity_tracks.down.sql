@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS activity_sample;
+DROP TABLE IF EXISTS activity_lap;
+
+ALTER TABLE activity DROP COLUMN source;
+ALTER TABLE activity DROP COLUMN max_heart_rate;
+ALTER TABLE activity DROP COLUMN elevation_gain;
+ALTER TABLE activity DROP COLUMN moving_time;
+
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+
+ALTER TABLE activity ADD COLUMN moving_time INT CHECK (moving_time >= 0);
+ALTER TABLE activity ADD COLUMN elevation_gain INT CHECK (elevation_gain >= 0);
+ALTER TABLE activity ADD COLUMN max_heart_rate INT CHECK (max_heart_rate > 0);
+ALTER TABLE activity ADD COLUMN source VARCHAR(10) NOT NULL DEFAULT 'manual';
+
+CREATE TABLE IF NOT EXISTS activity_lap (
+    activity_uid UUID NOT NULL REFERENCES activity(activity_uid) ON DELETE CASCADE,
+    "order" INT NOT NULL,
+    start_time timestamptz NOT NULL,
+    duration FLOAT NOT NULL,
+    distance FLOAT NOT NULL,
+    average_heart_rate INT,
+    max_heart_rate INT,
+    PRIMARY KEY (activity_uid, "order")
+);
+
+-- The samples of recorded activities, in the order they were recorded. Distance is the distance covered since
+-- the start of the activity.
+CREATE TABLE IF NOT EXISTS activity_sample (
+    activity_uid UUID NOT NULL REFERENCES activity(activity_uid) ON DELETE CASCADE,
+    "index" INT NOT NULL,
+    time timestamptz NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    elevation FLOAT,
+    distance FLOAT NOT NULL,
+    heart_rate INT,
+    cadence INT,
+    power INT,
+    PRIMARY KEY (activity_uid, "index")
+);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS plan_schedule;
+
+ALTER TABLE profile DROP COLUMN calendar_token;
+ALTER TABLE plan DROP COLUMN revision;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- The revision counts the changes to a plan, so calendar feeds can tell clients that scheduled days have changed.
+ALTER TABLE plan ADD COLUMN revision INT NOT NULL DEFAULT 0;
+
+-- The secret token of the calendar feed, which calendar clients use instead of logging in.
+ALTER TABLE profile ADD COLUMN calendar_token VARCHAR(64) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS plan_schedule (
+    schedule_uid UUID NOT NULL PRIMARY KEY,
+    profile_uid UUID NOT NULL REFERENCES profile(profile_uid) ON DELETE CASCADE,
+    plan_uid UUID NOT NULL REFERENCES plan(plan_uid) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    revision INT NOT NULL DEFAULT 0,
+    created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS plan_schedule_profile_idx ON plan_schedule (profile_uid);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DELETE FROM plan_schedule WHERE start_date IS NULL;
+
+ALTER TABLE plan_schedule DROP COLUMN skipped_weeks;
+ALTER TABLE plan_schedule DROP CONSTRAINT plan_schedule_anchor_check;
+ALTER TABLE plan_schedule DROP COLUMN race_date;
+ALTER TABLE plan_schedule ALTER COLUMN start_date SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+-- A schedule either starts on a date, or ends on the date of a race.
+ALTER TABLE plan_schedule ALTER COLUMN start_date DROP NOT NULL;
+ALTER TABLE plan_schedule ADD COLUMN race_date DATE;
+ALTER TABLE plan_schedule ADD CONSTRAINT plan_schedule_anchor_check CHECK ((start_date IS NULL) <> (race_date IS NULL));
+
+-- The orders of the plan weeks that are left out of the schedule.
+ALTER TABLE plan_schedule ADD COLUMN skipped_weeks INT[] NOT NULL DEFAULT '{}';
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Maps the records imported from Airtable to the rows they became, so the import can be run again without
+-- creating duplicates.
+CREATE TABLE IF NOT EXISTS airtable_import (
+    airtable_id VARCHAR(32) NOT NULL PRIMARY KEY,
+    table_name VARCHAR(32) NOT NULL,
+    uid UUID NOT NULL,
+    imported_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
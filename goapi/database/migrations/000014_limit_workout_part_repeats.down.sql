@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_repeat_check;
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_repeat_check CHECK (repeat > 0);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Repeat blocks are run at most 100 times, like models.MaxRepeat. Larger counts stored before the limit are
+-- lowered to it.
+UPDATE workout_parts SET repeat = 100 WHERE repeat > 100;
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_repeat_check;
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_repeat_check CHECK (repeat BETWEEN 1 AND 100);
+
+COMMIT;
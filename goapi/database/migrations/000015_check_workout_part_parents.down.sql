@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_parent_fkey;
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_parent_uid_fkey
+    FOREIGN KEY (parent_uid) REFERENCES workout_parts (part_uid) ON DELETE CASCADE;
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_part_workout_key;
+
+COMMIT;
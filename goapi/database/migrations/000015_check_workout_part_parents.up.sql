@@ -0,0 +1,12 @@
+BEGIN;
+
+-- The parent of a part must belong to the same workout. Parts whose parent is in another workout are removed.
+DELETE FROM workout_parts AS child USING workout_parts AS parent
+    WHERE child.parent_uid = parent.part_uid AND child.workout_uid <> parent.workout_uid;
+
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_part_workout_key UNIQUE (part_uid, workout_uid);
+ALTER TABLE workout_parts DROP CONSTRAINT workout_parts_parent_uid_fkey;
+ALTER TABLE workout_parts ADD CONSTRAINT workout_parts_parent_fkey
+    FOREIGN KEY (parent_uid, workout_uid) REFERENCES workout_parts (part_uid, workout_uid) ON DELETE CASCADE;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- The content of every event served in a calendar feed, so the sequence of the event can be increased when its
+-- workouts or paces change without a change to the plan or the schedule.
+CREATE TABLE IF NOT EXISTS calendar_event (
+    schedule_uid UUID NOT NULL REFERENCES plan_schedule(schedule_uid) ON DELETE CASCADE,
+    day_uid UUID NOT NULL REFERENCES plan_day(day_uid) ON DELETE CASCADE,
+    content_hash CHAR(64) NOT NULL,
+    revision INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (schedule_uid, day_uid)
+);
+
+COMMIT;
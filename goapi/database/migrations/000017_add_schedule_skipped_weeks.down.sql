@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE plan_schedule ADD COLUMN skipped_weeks INT[] NOT NULL DEFAULT '{}';
+
+UPDATE plan_schedule AS s SET skipped_weeks = ARRAY(
+    SELECT w."order" FROM plan_schedule_skipped_week AS sw
+    JOIN plan_week AS w USING (week_uid)
+    WHERE sw.schedule_uid = s.schedule_uid);
+
+DROP TABLE IF EXISTS plan_schedule_skipped_week;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- The plan weeks that are left out of a schedule. They are referenced by id, since the orders of the weeks
+-- change when weeks are moved or removed, and are removed with the week.
+CREATE TABLE IF NOT EXISTS plan_schedule_skipped_week (
+    schedule_uid UUID NOT NULL REFERENCES plan_schedule(schedule_uid) ON DELETE CASCADE,
+    week_uid UUID NOT NULL REFERENCES plan_week(week_uid) ON DELETE CASCADE,
+    PRIMARY KEY (schedule_uid, week_uid)
+);
+
+INSERT INTO plan_schedule_skipped_week (schedule_uid, week_uid)
+    SELECT DISTINCT s.schedule_uid, w.week_uid
+    FROM plan_schedule AS s
+    JOIN plan_week AS w ON w.plan_uid = s.plan_uid AND w."order" = ANY(s.skipped_weeks);
+
+ALTER TABLE plan_schedule DROP COLUMN skipped_weeks;
+
+COMMIT;
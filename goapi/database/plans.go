@@ -0,0 +1,550 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"goapi/logger"
+	"goapi/models"
+)
+
+var ErrInvalidWeekOrder = errors.New("the week order is outside of the plan")
+
+type planClient interface {
+	GetPlans(ctx context.Context) ([]models.Plan, error)
+	GetPlan(ctx context.Context, id string) (models.Plan, error)
+	GetWeek(ctx context.Context, id string) (models.Week, error)
+	GetWeeksForPlan(ctx context.Context, planId string) ([]models.Week, error)
+	GetDaysForWeek(ctx context.Context, weekId string) ([]models.Day, error)
+	GetDay(ctx context.Context, id string) (models.Day, error)
+	GetWorkoutsForDay(ctx context.Context, dayId string) ([]models.Workout, error)
+	GetWorkoutsForDays(ctx context.Context, dayIds []string) (map[string][]models.Workout, error)
+	CreatePlan(ctx context.Context, name, description, createdById string) (models.Plan, error)
+	UpdatePlan(ctx context.Context, id, name, description string) (models.Plan, error)
+	DeletePlan(ctx context.Context, id string) error
+	AddWeek(ctx context.Context, planId string, order int) (models.Plan, error)
+	MoveWeek(ctx context.Context, weekId string, order int) (models.Plan, error)
+	RemoveWeek(ctx context.Context, weekId string) (models.Plan, error)
+	SetDayWorkouts(ctx context.Context, weekId string, day int, workoutIds []string) (models.Plan, error)
+}
+
+func (c *client) GetPlans(ctx context.Context) ([]models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT p.plan_uid, p.name, COALESCE(p.description, ''), p.created_by_uid, p.revision
+				FROM plan AS p
+				ORDER BY p.created_at;`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Plan{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	var plans []models.Plan
+	for rows.Next() {
+		var plan models.Plan
+		err = rows.Scan(&plan.Id, &plan.Name, &plan.Description, &plan.CreatedBy, &plan.Revision)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Plan{}, err
+		}
+		plans = append(plans, plan)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Plan{}, err
+	}
+
+	return plans, nil
+}
+
+func (c *client) GetPlan(ctx context.Context, id string) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT p.plan_uid, p.name, COALESCE(p.description, ''), p.created_by_uid, p.revision
+				FROM plan AS p
+				WHERE p.plan_uid = $1`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var plan models.Plan
+	err := row.Scan(&plan.Id, &plan.Name, &plan.Description, &plan.CreatedBy, &plan.Revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Plan not found")
+			return models.Plan{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Plan{}, err
+	}
+
+	return plan, nil
+}
+
+func (c *client) GetWeek(ctx context.Context, id string) (models.Week, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT w.week_uid, w.plan_uid, w."order"
+				FROM plan_week AS w
+				WHERE w.week_uid = $1`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var week models.Week
+	err := row.Scan(&week.Id, &week.PlanId, &week.Order)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Week not found")
+			return models.Week{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Week{}, err
+	}
+
+	return week, nil
+}
+
+func (c *client) GetDay(ctx context.Context, id string) (models.Day, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT d.day_uid, d.week_uid, d.day
+				FROM plan_day AS d
+				WHERE d.day_uid = $1`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var day models.Day
+	err := row.Scan(&day.Id, &day.WeekId, &day.Day)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Day not found")
+			return models.Day{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Day{}, err
+	}
+
+	return day, nil
+}
+
+func (c *client) GetWeeksForPlan(ctx context.Context, planId string) ([]models.Week, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT w.week_uid, w.plan_uid, w."order"
+				FROM plan_week AS w
+				WHERE w.plan_uid = $1
+				ORDER BY w."order";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, planId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Week{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	var weeks []models.Week
+	for rows.Next() {
+		var week models.Week
+		err = rows.Scan(&week.Id, &week.PlanId, &week.Order)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Week{}, err
+		}
+		weeks = append(weeks, week)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Week{}, err
+	}
+
+	return weeks, nil
+}
+
+func (c *client) GetDaysForWeek(ctx context.Context, weekId string) ([]models.Day, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT d.day_uid, d.week_uid, d.day
+				FROM plan_day AS d
+				WHERE d.week_uid = $1
+				ORDER BY d.day;`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, weekId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Day{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	var days []models.Day
+	for rows.Next() {
+		var day models.Day
+		err = rows.Scan(&day.Id, &day.WeekId, &day.Day)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Day{}, err
+		}
+		days = append(days, day)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Day{}, err
+	}
+
+	return days, nil
+}
+
+func (c *client) GetWorkoutsForDay(ctx context.Context, dayId string) ([]models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT
+       			w.workout_uid, w.name, w.description, w.created_by_uid
+				FROM plan_day_workout AS dw
+				JOIN workout AS w USING(workout_uid)
+				WHERE dw.day_uid = $1
+				ORDER BY dw."order";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, dayId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.Workout{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	var workouts []models.Workout
+	for rows.Next() {
+		var workout models.Workout
+		err = rows.Scan(&workout.Id, &workout.Name, &workout.Description, &workout.CreatedBy)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.Workout{}, err
+		}
+		workouts = append(workouts, workout)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.Workout{}, err
+	}
+
+	return workouts, nil
+}
+
+// GetWorkoutsForDays returns the workouts of several days in one query, by day id.
+func (c *client) GetWorkoutsForDays(ctx context.Context, dayIds []string) (map[string][]models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT
+       			dw.day_uid, w.workout_uid, w.name, w.description, w.created_by_uid
+				FROM plan_day_workout AS dw
+				JOIN workout AS w USING(workout_uid)
+				WHERE dw.day_uid = ANY($1::uuid[])
+				ORDER BY dw."order";`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, pq.Array(dayIds))
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	workouts := make(map[string][]models.Workout)
+	for rows.Next() {
+		var dayId string
+		var workout models.Workout
+		err = rows.Scan(&dayId, &workout.Id, &workout.Name, &workout.Description, &workout.CreatedBy)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return nil, err
+		}
+		workouts[dayId] = append(workouts[dayId], workout)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return nil, err
+	}
+
+	return workouts, nil
+}
+
+func (c *client) CreatePlan(ctx context.Context, name, description, createdById string) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	id := createNewId()
+
+	sqlStatement :=
+		`INSERT INTO plan (plan_uid, name, description, created_by_uid)
+			VALUES ($1, $2, $3, $4)`
+
+	_, err := c.db.ExecContext(ctx, sqlStatement, id, name, description, createdById)
+	if err != nil {
+		log.WithError(err).Error("error during insert to db")
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, id)
+}
+
+func (c *client) UpdatePlan(ctx context.Context, id, name, description string) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement := `UPDATE plan SET name = $2, description = $3, revision = revision + 1 WHERE plan_uid = $1`
+
+	_, err := c.db.ExecContext(ctx, sqlStatement, id, name, description)
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, id)
+}
+
+func (c *client) DeletePlan(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	sqlStatement := `DELETE FROM plan WHERE plan_uid = $1`
+
+	_, err := c.db.ExecContext(ctx, sqlStatement, id)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+
+	return nil
+}
+
+// AddWeek inserts a new week at the given order, moving the following weeks one step back.
+// A negative order appends the week to the end of the plan.
+func (c *client) AddWeek(ctx context.Context, planId string, order int) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		numberOfWeeks, err := countWeeks(ctx, tx, planId)
+		if err != nil {
+			return err
+		}
+		if order < 0 {
+			order = numberOfWeeks
+		}
+		if order > numberOfWeeks {
+			return ErrInvalidWeekOrder
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE plan_week SET "order" = "order" + 1 WHERE plan_uid = $1 AND "order" >= $2`,
+			planId, order)
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO plan_week (week_uid, plan_uid, "order") VALUES ($1, $2, $3)`,
+			createNewId(), planId, order)
+		if err != nil {
+			log.WithError(err).Error("error during insert to db")
+			return err
+		}
+		return bumpRevision(ctx, tx, planId)
+	})
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, planId)
+}
+
+// MoveWeek moves a week to the given order, shifting the weeks in between.
+func (c *client) MoveWeek(ctx context.Context, weekId string, order int) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	week, err := c.GetWeek(ctx, weekId)
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		numberOfWeeks, err := countWeeks(ctx, tx, week.PlanId)
+		if err != nil {
+			return err
+		}
+		if order < 0 || order >= numberOfWeeks {
+			return ErrInvalidWeekOrder
+		}
+
+		if order < week.Order {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE plan_week SET "order" = "order" + 1 WHERE plan_uid = $1 AND "order" >= $2 AND "order" < $3`,
+				week.PlanId, order, week.Order)
+		} else {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE plan_week SET "order" = "order" - 1 WHERE plan_uid = $1 AND "order" > $2 AND "order" <= $3`,
+				week.PlanId, week.Order, order)
+		}
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE plan_week SET "order" = $2 WHERE week_uid = $1`, weekId, order)
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+		return bumpRevision(ctx, tx, week.PlanId)
+	})
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, week.PlanId)
+}
+
+// RemoveWeek deletes a week with its days, and closes the gap it leaves in the plan.
+func (c *client) RemoveWeek(ctx context.Context, weekId string) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	week, err := c.GetWeek(ctx, weekId)
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM plan_week WHERE week_uid = $1`, weekId)
+		if err != nil {
+			log.WithError(err).Error("error during delete from db")
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE plan_week SET "order" = "order" - 1 WHERE plan_uid = $1 AND "order" > $2`,
+			week.PlanId, week.Order)
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+		return bumpRevision(ctx, tx, week.PlanId)
+	})
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, week.PlanId)
+}
+
+// SetDayWorkouts replaces the workouts of a day in a week. The day is removed when no workouts are given.
+func (c *client) SetDayWorkouts(ctx context.Context, weekId string, day int, workoutIds []string) (models.Plan, error) {
+	log := logger.FromContext(ctx)
+
+	week, err := c.GetWeek(ctx, weekId)
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		if len(workoutIds) == 0 {
+			_, err := tx.ExecContext(ctx, `DELETE FROM plan_day WHERE week_uid = $1 AND day = $2`, weekId, day)
+			if err != nil {
+				log.WithError(err).Error("error during delete from db")
+				return err
+			}
+			return bumpRevision(ctx, tx, week.PlanId)
+		}
+
+		var dayId string
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO plan_day (day_uid, week_uid, day) VALUES ($1, $2, $3)
+				ON CONFLICT (week_uid, day) DO UPDATE SET day = EXCLUDED.day
+				RETURNING day_uid`,
+			createNewId(), weekId, day).Scan(&dayId)
+		if err != nil {
+			log.WithError(err).Error("error during insert to db")
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM plan_day_workout WHERE day_uid = $1`, dayId)
+		if err != nil {
+			log.WithError(err).Error("error during delete from db")
+			return err
+		}
+
+		for order, workoutId := range workoutIds {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO plan_day_workout (day_uid, workout_uid, "order") VALUES ($1, $2, $3)`,
+				dayId, workoutId, order)
+			if err != nil {
+				log.WithError(err).Error("error during insert to db")
+				return err
+			}
+		}
+		return bumpRevision(ctx, tx, week.PlanId)
+	})
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	return c.GetPlan(ctx, week.PlanId)
+}
+
+func countWeeks(ctx context.Context, tx *sql.Tx, planId string) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM plan_week WHERE plan_uid = $1`, planId).Scan(&count)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Error while parsing db row")
+		return 0, err
+	}
+	return count, nil
+}
+
+// bumpRevision counts a change to the weeks or days of a plan.
+func bumpRevision(ctx context.Context, tx *sql.Tx, planId string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE plan SET revision = revision + 1 WHERE plan_uid = $1`, planId)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("error during update of db")
+	}
+	return err
+}
@@ -3,21 +3,30 @@ package database
 import (
 	"context"
 	"database/sql"
+	"github.com/lib/pq"
 	"goapi/logger"
 	"goapi/models"
+	"time"
 )
 
 type profileClient interface {
 	GetProfiles(ctx context.Context) ([]models.Profile, error)
 	GetProfile(ctx context.Context, id string) (models.Profile, error)
 	GetProfileByAuth0Id(ctx context.Context, auth0Id string) (models.Profile, error)
+	UpdateProfile(ctx context.Context, profile models.Profile) (models.Profile, error)
 	GetRecords(ctx context.Context, profileId string) ([]models.Record, error)
+	GetRecord(ctx context.Context, id string) (models.Record, error)
+	AddRecord(ctx context.Context, profileId, race string, distance, duration int, date time.Time) (models.Record, error)
+	DeleteRecord(ctx context.Context, id string) error
 }
 
 func (c *client) GetProfiles(ctx context.Context) ([]models.Profile, error) {
 	log := logger.FromContext(ctx)
 
-	sqlStatement := `SELECT profile_uid, first_name, last_name, vdot FROM profile;`
+	sqlStatement :=
+		`SELECT profile_uid, first_name, last_name, COALESCE(vdot, 0),
+			COALESCE(max_heart_rate, 0), COALESCE(resting_heart_rate, 0), COALESCE(threshold_power, 0)
+			FROM profile;`
 
 	rows, err := c.db.Query(sqlStatement)
 	if err != nil {
@@ -34,7 +43,8 @@ func (c *client) GetProfiles(ctx context.Context) ([]models.Profile, error) {
 	var profiles []models.Profile
 	for rows.Next() {
 		var profile models.Profile
-		err = rows.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot)
+		err = rows.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot,
+			&profile.MaxHeartRate, &profile.RestingHeartRate, &profile.ThresholdPower)
 		if err != nil {
 			log.WithError(err).Error("Error while parsing db row")
 			return []models.Profile{}, err
@@ -55,11 +65,14 @@ func (c *client) GetProfile(ctx context.Context, id string) (models.Profile, err
 	log := logger.FromContext(ctx)
 
 	sqlStatement :=
-		`SELECT profile_uid, first_name, last_name, vdot FROM profile WHERE profile_uid = $1;`
+		`SELECT profile_uid, first_name, last_name, COALESCE(vdot, 0),
+			COALESCE(max_heart_rate, 0), COALESCE(resting_heart_rate, 0), COALESCE(threshold_power, 0)
+			FROM profile WHERE profile_uid = $1;`
 
 	row := c.db.QueryRowContext(ctx, sqlStatement, id)
 	var profile models.Profile
-	err := row.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot)
+	err := row.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot,
+		&profile.MaxHeartRate, &profile.RestingHeartRate, &profile.ThresholdPower)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			notFoundError := newEntityNotFoundError(err)
@@ -77,11 +90,14 @@ func (c *client) GetProfileByAuth0Id(ctx context.Context, auth0Id string) (model
 	log := logger.FromContext(ctx)
 
 	sqlStatement :=
-		`SELECT profile_uid, first_name, last_name, vdot FROM profile WHERE auth0_id = $1;`
+		`SELECT profile_uid, first_name, last_name, COALESCE(vdot, 0),
+			COALESCE(max_heart_rate, 0), COALESCE(resting_heart_rate, 0), COALESCE(threshold_power, 0)
+			FROM profile WHERE auth0_id = $1;`
 
 	row := c.db.QueryRowContext(ctx, sqlStatement, auth0Id)
 	var profile models.Profile
-	err := row.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot)
+	err := row.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot,
+		&profile.MaxHeartRate, &profile.RestingHeartRate, &profile.ThresholdPower)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			notFoundError := newEntityNotFoundError(err)
@@ -95,10 +111,50 @@ func (c *client) GetProfileByAuth0Id(ctx context.Context, auth0Id string) (model
 	return profile, nil
 }
 
+// copyDefaultIntensities gives a new profile its own copy of the default intensities.
+func copyDefaultIntensities(ctx context.Context, tx *sql.Tx, profileId string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO intensity (intensity_uid, created_by_uid, name, description, coefficient, "order",
+				heart_rate_max_min, heart_rate_max_max, heart_rate_reserve_min, heart_rate_reserve_max,
+				vdot_min, vdot_max, rpe_min, rpe_max, power_min, power_max)
+			SELECT uuid_generate_v4(), $1, name, description, coefficient, "order",
+				heart_rate_max_min, heart_rate_max_max, heart_rate_reserve_min, heart_rate_reserve_max,
+				vdot_min, vdot_max, rpe_min, rpe_max, power_min, power_max
+			FROM default_intensity`,
+		profileId)
+	return err
+}
+
+// UpdateProfile saves the names, VDOT, heart rates and threshold power of a profile. Zero values are saved as not set.
+func (c *client) UpdateProfile(ctx context.Context, profile models.Profile) (models.Profile, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`UPDATE profile SET first_name = $2, last_name = $3, vdot = $4,
+			max_heart_rate = $5, resting_heart_rate = $6, threshold_power = $7
+			WHERE profile_uid = $1`
+
+	result, err := c.db.ExecContext(ctx, sqlStatement, profile.Id, profile.FirstName, profile.LastName, profile.Vdot,
+		nullInt(profile.MaxHeartRate), nullInt(profile.RestingHeartRate), nullInt(profile.ThresholdPower))
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return models.Profile{}, err
+	}
+	err = ensureRowsAffected(ctx, result)
+	if err != nil {
+		return models.Profile{}, err
+	}
+
+	return c.GetProfile(ctx, profile.Id)
+}
+
 func (c *client) GetRecords(ctx context.Context, profileId string) ([]models.Record, error) {
 	log := logger.FromContext(ctx)
 
-	sqlStatement := `SELECT record_uid, race, duration FROM record WHERE profile_uid=$1;`
+	sqlStatement :=
+		`SELECT record_uid, profile_uid, race, COALESCE(distance, 0), COALESCE(duration, 0), date
+			FROM record WHERE profile_uid=$1
+			ORDER BY date DESC NULLS LAST;`
 
 	rows, err := c.db.Query(sqlStatement, profileId)
 	if err != nil {
@@ -115,11 +171,13 @@ func (c *client) GetRecords(ctx context.Context, profileId string) ([]models.Rec
 	var records []models.Record
 	for rows.Next() {
 		var record models.Record
-		err = rows.Scan(&record.Id, &record.Race, &record.Duration)
+		var date pq.NullTime
+		err = rows.Scan(&record.Id, &record.ProfileId, &record.Race, &record.Distance, &record.Duration, &date)
 		if err != nil {
 			log.WithError(err).Error("Error while parsing db row")
 			return []models.Record{}, err
 		}
+		record.Date = date.Time
 		records = append(records, record)
 	}
 	// get any error encountered during iteration
@@ -130,4 +188,60 @@ func (c *client) GetRecords(ctx context.Context, profileId string) ([]models.Rec
 	}
 
 	return records, nil
-}
\ No newline at end of file
+}
+
+func (c *client) GetRecord(ctx context.Context, id string) (models.Record, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT record_uid, profile_uid, race, COALESCE(distance, 0), COALESCE(duration, 0), date
+			FROM record WHERE record_uid = $1;`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, id)
+	var record models.Record
+	var date pq.NullTime
+	err := row.Scan(&record.Id, &record.ProfileId, &record.Race, &record.Distance, &record.Duration, &date)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Record not found")
+			return models.Record{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Record{}, err
+	}
+	record.Date = date.Time
+
+	return record, nil
+}
+
+func (c *client) AddRecord(ctx context.Context, profileId, race string, distance, duration int, date time.Time) (models.Record, error) {
+	log := logger.FromContext(ctx)
+
+	id := createNewId()
+
+	sqlStatement :=
+		`INSERT INTO record (record_uid, profile_uid, race, distance, duration, date)
+			VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := c.db.ExecContext(ctx, sqlStatement, id, profileId, race, distance, duration,
+		pq.NullTime{Time: date, Valid: !date.IsZero()})
+	if err != nil {
+		log.WithError(err).Error("error during insert to db")
+		return models.Record{}, err
+	}
+
+	return c.GetRecord(ctx, id)
+}
+
+func (c *client) DeleteRecord(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	_, err := c.db.ExecContext(ctx, `DELETE FROM record WHERE record_uid = $1`, id)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+
+	return nil
+}
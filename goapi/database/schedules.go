@@ -0,0 +1,347 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"github.com/lib/pq"
+	"goapi/logger"
+	"goapi/models"
+)
+
+// calendarTokenBytes is the length of the random calendar tokens, before they are hex encoded.
+const calendarTokenBytes = 32
+
+type scheduleClient interface {
+	GetSchedulesForProfile(ctx context.Context, profileId string) ([]models.PlanSchedule, error)
+	GetSchedule(ctx context.Context, id string) (models.PlanSchedule, error)
+	CreateSchedule(ctx context.Context, schedule models.PlanSchedule) (models.PlanSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule models.PlanSchedule) (models.PlanSchedule, error)
+	DeleteSchedule(ctx context.Context, id string) error
+	GetCalendarToken(ctx context.Context, profileId string) (string, error)
+	ResetCalendarToken(ctx context.Context, profileId string) (string, error)
+	GetProfileByCalendarToken(ctx context.Context, token string) (models.Profile, error)
+	UpdateCalendarEvents(ctx context.Context, scheduleId string, hashes map[string]string) (map[string]int, error)
+}
+
+// The skipped weeks are stored by week id, and read as the orders the weeks have now.
+const scheduleColumns = `s.schedule_uid, s.profile_uid, s.plan_uid, s.start_date, s.race_date, s.time_zone,
+	ARRAY(SELECT w."order" FROM plan_schedule_skipped_week AS sw JOIN plan_week AS w USING (week_uid)
+		WHERE sw.schedule_uid = s.schedule_uid ORDER BY w."order"),
+	s.revision`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSchedule(row scanner) (models.PlanSchedule, error) {
+	var schedule models.PlanSchedule
+	var startDate, raceDate pq.NullTime
+	var skippedWeeks pq.Int64Array
+	err := row.Scan(&schedule.Id, &schedule.ProfileId, &schedule.PlanId, &startDate, &raceDate, &schedule.TimeZone,
+		&skippedWeeks, &schedule.Revision)
+	if err != nil {
+		return models.PlanSchedule{}, err
+	}
+
+	schedule.StartDate, schedule.RaceDate = startDate.Time, raceDate.Time
+	schedule.SkippedWeeks = []int{}
+	for _, week := range skippedWeeks {
+		schedule.SkippedWeeks = append(schedule.SkippedWeeks, int(week))
+	}
+	return schedule, nil
+}
+
+func skippedWeeksArray(weeks []int) pq.Int64Array {
+	array := pq.Int64Array{}
+	for _, week := range weeks {
+		array = append(array, int64(week))
+	}
+	return array
+}
+
+// saveSkippedWeeks replaces the skipped weeks of a schedule with the weeks of its plan that have the orders.
+func saveSkippedWeeks(ctx context.Context, tx *sql.Tx, scheduleId string, weekOrders []int) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM plan_schedule_skipped_week WHERE schedule_uid = $1`, scheduleId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO plan_schedule_skipped_week (schedule_uid, week_uid)
+			SELECT s.schedule_uid, w.week_uid
+			FROM plan_schedule AS s
+			JOIN plan_week AS w USING (plan_uid)
+			WHERE s.schedule_uid = $1 AND w."order" = ANY($2)`,
+		scheduleId, skippedWeeksArray(weekOrders))
+	return err
+}
+
+func (c *client) GetSchedulesForProfile(ctx context.Context, profileId string) ([]models.PlanSchedule, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT ` + scheduleColumns + `
+			FROM plan_schedule AS s
+			WHERE s.profile_uid = $1
+			ORDER BY COALESCE(s.start_date, s.race_date);`
+
+	rows, err := c.db.QueryContext(ctx, sqlStatement, profileId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return []models.PlanSchedule{}, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	schedules := []models.PlanSchedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			log.WithError(err).Error("Error while parsing db row")
+			return []models.PlanSchedule{}, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	// get any error encountered during iteration
+	err = rows.Err()
+	if err != nil {
+		log.WithError(err).Error("Error while parsing db rows")
+		return []models.PlanSchedule{}, err
+	}
+
+	return schedules, nil
+}
+
+func (c *client) GetSchedule(ctx context.Context, id string) (models.PlanSchedule, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement := `SELECT ` + scheduleColumns + ` FROM plan_schedule AS s WHERE s.schedule_uid = $1;`
+
+	schedule, err := scanSchedule(c.db.QueryRowContext(ctx, sqlStatement, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Schedule not found")
+			return models.PlanSchedule{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.PlanSchedule{}, err
+	}
+
+	return schedule, nil
+}
+
+func (c *client) CreateSchedule(ctx context.Context, schedule models.PlanSchedule) (models.PlanSchedule, error) {
+	log := logger.FromContext(ctx)
+
+	id := createNewId()
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO plan_schedule (schedule_uid, profile_uid, plan_uid, start_date, race_date, time_zone)
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, schedule.ProfileId, schedule.PlanId, nullTime(schedule.StartDate), nullTime(schedule.RaceDate),
+			schedule.TimeZone)
+		if err != nil {
+			return err
+		}
+		return saveSkippedWeeks(ctx, tx, id, schedule.SkippedWeeks)
+	})
+	if err != nil {
+		log.WithError(err).Error("error during insert to db")
+		return models.PlanSchedule{}, err
+	}
+
+	return c.GetSchedule(ctx, id)
+}
+
+// UpdateSchedule saves the dates, time zone and skipped weeks of a schedule, and counts the change.
+func (c *client) UpdateSchedule(ctx context.Context, schedule models.PlanSchedule) (models.PlanSchedule, error) {
+	log := logger.FromContext(ctx)
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE plan_schedule
+				SET start_date = $2, race_date = $3, time_zone = $4, revision = revision + 1
+				WHERE schedule_uid = $1`,
+			schedule.Id, nullTime(schedule.StartDate), nullTime(schedule.RaceDate), schedule.TimeZone)
+		if err != nil {
+			return err
+		}
+		return saveSkippedWeeks(ctx, tx, schedule.Id, schedule.SkippedWeeks)
+	})
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return models.PlanSchedule{}, err
+	}
+
+	return c.GetSchedule(ctx, schedule.Id)
+}
+
+func (c *client) DeleteSchedule(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	_, err := c.db.ExecContext(ctx, `DELETE FROM plan_schedule WHERE schedule_uid = $1`, id)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+
+	return nil
+}
+
+// GetCalendarToken returns the token of the calendar feed of a profile, or an empty string if it has none.
+func (c *client) GetCalendarToken(ctx context.Context, profileId string) (string, error) {
+	log := logger.FromContext(ctx)
+
+	var token sql.NullString
+	err := c.db.QueryRowContext(ctx, `SELECT calendar_token FROM profile WHERE profile_uid = $1`, profileId).Scan(&token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Error("Profile not found")
+			return "", notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return "", err
+	}
+
+	return token.String, nil
+}
+
+// ResetCalendarToken gives a profile a new calendar token, which stops the old feed URL from working.
+func (c *client) ResetCalendarToken(ctx context.Context, profileId string) (string, error) {
+	log := logger.FromContext(ctx)
+
+	random := make([]byte, calendarTokenBytes)
+	_, err := rand.Read(random)
+	if err != nil {
+		log.WithError(err).Error("Could not generate a calendar token")
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+
+	_, err = c.db.ExecContext(ctx, `UPDATE profile SET calendar_token = $2 WHERE profile_uid = $1`, profileId, token)
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (c *client) GetProfileByCalendarToken(ctx context.Context, token string) (models.Profile, error) {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`SELECT profile_uid, first_name, last_name, COALESCE(vdot, 0),
+			COALESCE(max_heart_rate, 0), COALESCE(resting_heart_rate, 0), COALESCE(threshold_power, 0)
+			FROM profile WHERE calendar_token = $1;`
+
+	row := c.db.QueryRowContext(ctx, sqlStatement, token)
+	var profile models.Profile
+	err := row.Scan(&profile.Id, &profile.FirstName, &profile.LastName, &profile.Vdot,
+		&profile.MaxHeartRate, &profile.RestingHeartRate, &profile.ThresholdPower)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			notFoundError := newEntityNotFoundError(err)
+			log.WithError(notFoundError).Warn("No profile with the calendar token")
+			return models.Profile{}, notFoundError
+		}
+		log.WithError(err).Error("Error while parsing db row")
+		return models.Profile{}, err
+	}
+
+	return profile, nil
+}
+
+// UpdateCalendarEvents saves the content hashes of the calendar events of a schedule, by day id, and returns the
+// revision of every event. The revision of an event grows every time its hash changes. Most feed polls change
+// nothing, and then only read.
+func (c *client) UpdateCalendarEvents(ctx context.Context, scheduleId string, hashes map[string]string) (map[string]int, error) {
+	log := logger.FromContext(ctx)
+
+	stored, err := storedCalendarEvents(ctx, c.db, scheduleId)
+	if err != nil {
+		log.WithError(err).Error("Error querying db")
+		return nil, err
+	}
+
+	revisions, changed := map[string]int{}, map[string]string{}
+	for dayId, hash := range hashes {
+		if event, ok := stored[dayId]; ok && event.hash == hash {
+			revisions[dayId] = event.revision
+		} else {
+			changed[dayId] = hash
+		}
+	}
+	if len(changed) == 0 {
+		return revisions, nil
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		for dayId, hash := range changed {
+			// A feed fetched at the same time may have saved the hash already, then the update returns no row.
+			var revision int
+			err := tx.QueryRowContext(ctx,
+				`INSERT INTO calendar_event (schedule_uid, day_uid, content_hash) VALUES ($1, $2, $3)
+					ON CONFLICT (schedule_uid, day_uid) DO UPDATE
+					SET content_hash = EXCLUDED.content_hash, revision = calendar_event.revision + 1
+					WHERE calendar_event.content_hash <> EXCLUDED.content_hash
+					RETURNING revision`,
+				scheduleId, dayId, hash).Scan(&revision)
+			if err == sql.ErrNoRows {
+				err = tx.QueryRowContext(ctx,
+					`SELECT revision FROM calendar_event WHERE schedule_uid = $1 AND day_uid = $2`,
+					scheduleId, dayId).Scan(&revision)
+			}
+			if err != nil {
+				return err
+			}
+			revisions[dayId] = revision
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("error during update of calendar events")
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+type calendarEvent struct {
+	hash     string
+	revision int
+}
+
+func storedCalendarEvents(ctx context.Context, db querier, scheduleId string) (map[string]calendarEvent, error) {
+	log := logger.FromContext(ctx)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT day_uid, content_hash, revision FROM calendar_event WHERE schedule_uid = $1`, scheduleId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+		}
+	}()
+
+	events := map[string]calendarEvent{}
+	for rows.Next() {
+		var dayId string
+		var event calendarEvent
+		if err := rows.Scan(&dayId, &event.hash, &event.revision); err != nil {
+			return nil, err
+		}
+		events[dayId] = event
+	}
+	return events, rows.Err()
+}
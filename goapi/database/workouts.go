@@ -3,6 +3,8 @@ package database
 import (
 	"context"
 	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 	"goapi/logger"
 	"goapi/models"
 	"sort"
@@ -11,45 +13,111 @@ import (
 type workoutClient interface {
 	GetWorkouts(ctx context.Context) ([]models.Workout, error)
 	GetWorkout(ctx context.Context, id string) (models.Workout, error)
-	CreateWorkout(ctx context.Context, name, description, createdById string) (models.Workout, error)
+	CreateWorkout(ctx context.Context, name, description string, parts []models.WorkoutPart, createdById string) (models.Workout, error)
 	GetWorkoutPartsForWorkout(ctx context.Context, workoutId string) ([]models.WorkoutPart, error)
-	AddWorkoutPart(ctx context.Context, workoutId string, order int, distance int, metric, intensityId, createdById string) (models.Workout, error)
+	GetWorkoutPartsForWorkouts(ctx context.Context, workoutIds []string) (map[string][]models.WorkoutPart, error)
+	AddWorkoutPart(ctx context.Context, workoutId, parentId string, order int, distance int, metric, intensityId string, repeat int, createdById string) (models.Workout, error)
+	UpdateWorkout(ctx context.Context, id, name, description string) (models.Workout, error)
+	UpdateWorkoutWithParts(ctx context.Context, id, name, description string, parts []models.WorkoutPart, createdById string) (models.Workout, error)
+	DeleteWorkout(ctx context.Context, id string) error
+	UpdateWorkoutPart(ctx context.Context, workoutId, parentId string, order int, distance int, metric, intensityId string, repeat int) (models.Workout, error)
+	RemoveWorkoutPart(ctx context.Context, workoutId, parentId string, order int) (models.Workout, error)
+	ReorderWorkoutParts(ctx context.Context, workoutId, parentId string, orders []int) (models.Workout, error)
 }
 
-func (c *client) CreateWorkout(ctx context.Context, name, description, createdById string) (models.Workout, error) {
+var ErrInvalidPartOrder = errors.New("the new order must contain every part of the workout exactly once")
+
+// CreateWorkout creates a workout together with its tree of parts.
+func (c *client) CreateWorkout(ctx context.Context, name, description string, parts []models.WorkoutPart, createdById string) (models.Workout, error) {
 	log := logger.FromContext(ctx)
 
+	err := models.CheckWorkoutParts(parts)
+	if err != nil {
+		return models.Workout{}, err
+	}
 	id := createNewId()
 
-	sqlStatement :=
-		`INSERT INTO workout (workout_uid, name, description, created_by_uid)
-			VALUES ($1, $2, $3, $4)`
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		sqlStatement :=
+			`INSERT INTO workout (workout_uid, name, description, created_by_uid)
+				VALUES ($1, $2, $3, $4)`
 
-	_, err := c.db.Exec(sqlStatement, id, name, description, createdById)
+		_, err := tx.ExecContext(ctx, sqlStatement, id, name, description, createdById)
+		if err != nil {
+			log.WithError(err).Error("error during insert to db")
+			return err
+		}
+
+		return insertWorkoutParts(ctx, tx, id, "", parts, createdById)
+	})
 	if err != nil {
-		log.WithError(err).Error("error during insert to db")
 		return models.Workout{}, err
 	}
 
 	return c.GetWorkout(ctx, id)
 }
 
-func (c *client) AddWorkoutPart(ctx context.Context, workoutId string, order, distance int, metric, intensityId, createdById string) (models.Workout, error) {
+// AddWorkoutPart adds a part to a workout, or to the repeat block given by parentId.
+// A repeat larger than zero creates an empty repeat block instead of a plain part.
+func (c *client) AddWorkoutPart(ctx context.Context, workoutId, parentId string, order, distance int, metric, intensityId string, repeat int, createdById string) (models.Workout, error) {
 	log := logger.FromContext(ctx)
 
-	sqlStatement :=
-		`INSERT INTO workout_parts (workout_uid, "order", distance, metric, intensity_uid, created_by_uid)
-			VALUES ($1, $2, $3, $4, $5, $6)`
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		if parentId != "" {
+			err := ensureRepeatBlock(ctx, tx, workoutId, parentId)
+			if err != nil {
+				return err
+			}
+		}
+
+		sqlStatement :=
+			`INSERT INTO workout_parts (part_uid, workout_uid, parent_uid, "order", distance, metric, intensity_uid, repeat, created_by_uid)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+		_, err := tx.ExecContext(ctx, sqlStatement,
+			createNewId(), workoutId, nullString(parentId), order, distance, nullString(metric),
+			nullString(intensityId), nullInt(repeat), createdById)
+		if err != nil {
+			log.WithError(err).Error("error during insert to db")
+			return err
+		}
 
-	_, err := c.db.Exec(sqlStatement, workoutId, order, distance, metric, intensityId, createdById)
+		return checkWorkoutParts(ctx, tx, workoutId)
+	})
 	if err != nil {
-		log.WithError(err).Error("error during insert to db")
 		return models.Workout{}, err
 	}
 
 	return c.GetWorkout(ctx, workoutId)
 }
 
+// ensureRepeatBlock returns an EntityNotFound error unless the parent is a repeat block of the workout. The
+// parent is locked until the transaction ends, so it can not be removed while a part is added to it.
+func ensureRepeatBlock(ctx context.Context, tx *sql.Tx, workoutId, parentId string) error {
+	row := tx.QueryRowContext(ctx,
+		`SELECT part_uid FROM workout_parts WHERE part_uid = $1 AND workout_uid = $2 AND repeat IS NOT NULL FOR SHARE`,
+		parentId, workoutId)
+	var id string
+	err := row.Scan(&id)
+	if err == sql.ErrNoRows {
+		return newEntityNotFoundError(err)
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Error while parsing db row")
+	}
+	return err
+}
+
+// checkWorkoutParts verifies that the parts of a workout are within the limits of repeat blocks after they
+// are changed, so the transaction making the change can be rolled back.
+func checkWorkoutParts(ctx context.Context, db querier, workoutId string) error {
+	parts, err := queryWorkoutParts(ctx, db, workoutId)
+	if err != nil {
+		return err
+	}
+	return models.CheckWorkoutParts(parts)
+}
+
 func (c *client) GetWorkouts(ctx context.Context) ([]models.Workout, error) {
 	log := logger.FromContext(ctx)
 
@@ -118,20 +186,47 @@ func (c *client) GetWorkout(ctx context.Context, id string) (models.Workout, err
 	return workout, nil
 }
 
+// GetWorkoutPartsForWorkout returns the top level parts of a workout, with the parts of repeat blocks nested inside.
 func (c *client) GetWorkoutPartsForWorkout(ctx context.Context, workoutId string) ([]models.WorkoutPart, error) {
+	return queryWorkoutParts(ctx, c.db, workoutId)
+}
+
+// GetWorkoutPartsForWorkouts returns the parts of several workouts in one query, by workout id.
+func (c *client) GetWorkoutPartsForWorkouts(ctx context.Context, workoutIds []string) (map[string][]models.WorkoutPart, error) {
+	return queryWorkoutPartsForWorkouts(ctx, c.db, workoutIds)
+}
+
+// querier is either the database or a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryWorkoutParts(ctx context.Context, db querier, workoutId string) ([]models.WorkoutPart, error) {
+	parts, err := queryWorkoutPartsForWorkouts(ctx, db, []string{workoutId})
+	if err != nil {
+		return []models.WorkoutPart{}, err
+	}
+	return parts[workoutId], nil
+}
+
+// queryWorkoutPartsForWorkouts builds the part trees of several workouts. The top level parts of a workout
+// are found under the workout id, as part ids never collide with it.
+func queryWorkoutPartsForWorkouts(ctx context.Context, db querier, workoutIds []string) (map[string][]models.WorkoutPart, error) {
 	log := logger.FromContext(ctx)
 
 	sqlStatement :=
-		`SELECT wp."order", wp.distance, wp.metric,
-       			i.intensity_uid, i.name, i.description, i.coefficient 
+		`SELECT wp.part_uid, COALESCE(wp.parent_uid, wp.workout_uid)::text, wp."order", wp.distance,
+       			COALESCE(wp.metric::text, ''), COALESCE(wp.repeat, 0),
+       			COALESCE(i.intensity_uid::text, ''), COALESCE(i.name, ''), COALESCE(i.description, ''), COALESCE(i.coefficient, 0),
+       			` + intensityTargetColumns + `
 				FROM workout_parts AS wp
 			    LEFT JOIN intensity as i USING(intensity_uid)
-				WHERE wp.workout_uid = $1;`
+				WHERE wp.workout_uid = ANY($1::uuid[]);`
 
-	rows, err := c.db.QueryContext(ctx, sqlStatement, workoutId)
+	rows, err := db.QueryContext(ctx, sqlStatement, pq.Array(workoutIds))
 	if err != nil {
 		log.WithError(err).Error("Error querying db")
-		return []models.WorkoutPart{}, err
+		return nil, err
 	}
 	defer func() {
 		err := rows.Close()
@@ -140,32 +235,313 @@ func (c *client) GetWorkoutPartsForWorkout(ctx context.Context, workoutId string
 		}
 	}()
 
-	var workoutParts []models.WorkoutPart
+	partsByParent := make(map[string][]models.WorkoutPart)
 	for rows.Next() {
 		var workoutPart models.WorkoutPart
+		var parentId string
 		var intensity models.Intensity
-		err = rows.Scan(
-			&workoutPart.Order, &workoutPart.Distance, &workoutPart.Metric,
+		destinations := []interface{}{
+			&workoutPart.Id, &parentId, &workoutPart.Order, &workoutPart.Distance,
+			&workoutPart.Metric, &workoutPart.Repeat,
 			&intensity.Id, &intensity.Name, &intensity.Description, &intensity.Coefficient,
-		)
+		}
+		err = rows.Scan(append(destinations, intensityTargetDestinations(&intensity.Targets)...)...)
 		if err != nil {
 			log.WithError(err).Error("Error while parsing db row")
-			return []models.WorkoutPart{}, err
+			return nil, err
 		}
 
 		workoutPart.Intensity = intensity
-		workoutParts = append(workoutParts, workoutPart)
+		partsByParent[parentId] = append(partsByParent[parentId], workoutPart)
 	}
 	// get any error encountered during iteration
 	err = rows.Err()
 	if err != nil {
 		log.WithError(err).Error("Error while parsing db rows")
-		return []models.WorkoutPart{}, err
+		return nil, err
+	}
+
+	parts := make(map[string][]models.WorkoutPart)
+	for _, workoutId := range workoutIds {
+		parts[workoutId] = buildWorkoutPartTree(partsByParent, workoutId)
+	}
+	return parts, nil
+}
+
+func buildWorkoutPartTree(partsByParent map[string][]models.WorkoutPart, parentId string) []models.WorkoutPart {
+	parts := partsByParent[parentId]
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Order < parts[j].Order
+	})
+	for i := range parts {
+		if parts[i].IsRepeat() {
+			parts[i].Parts = buildWorkoutPartTree(partsByParent, parts[i].Id)
+		}
+	}
+	return parts
+}
+
+func insertWorkoutParts(ctx context.Context, tx *sql.Tx, workoutId, parentId string, parts []models.WorkoutPart, createdById string) error {
+	log := logger.FromContext(ctx)
+
+	sqlStatement :=
+		`INSERT INTO workout_parts (part_uid, workout_uid, parent_uid, "order", distance, metric, intensity_uid, repeat, created_by_uid)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	for order, part := range parts {
+		id := createNewId()
+		_, err := tx.ExecContext(ctx, sqlStatement,
+			id, workoutId, nullString(parentId), order, part.Distance, nullString(part.Metric),
+			nullString(part.Intensity.Id), nullInt(part.Repeat), createdById)
+		if err != nil {
+			log.WithError(err).Error("error during insert to db")
+			return err
+		}
+
+		if part.IsRepeat() {
+			err = insertWorkoutParts(ctx, tx, workoutId, id, part.Parts, createdById)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *client) UpdateWorkout(ctx context.Context, id, name, description string) (models.Workout, error) {
+	err := updateWorkout(ctx, c.db, id, name, description)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	return c.GetWorkout(ctx, id)
+}
+
+// UpdateWorkoutWithParts updates a workout and replaces every part of it with the given tree of parts, in one
+// transaction.
+func (c *client) UpdateWorkoutWithParts(ctx context.Context, id, name, description string, parts []models.WorkoutPart, createdById string) (models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	err := models.CheckWorkoutParts(parts)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	err = c.inTransaction(ctx, func(tx *sql.Tx) error {
+		err := updateWorkout(ctx, tx, id, name, description)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM workout_parts WHERE workout_uid = $1`, id)
+		if err != nil {
+			log.WithError(err).Error("error during delete from db")
+			return err
+		}
+
+		return insertWorkoutParts(ctx, tx, id, "", parts, createdById)
+	})
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	return c.GetWorkout(ctx, id)
+}
+
+func updateWorkout(ctx context.Context, db execer, id, name, description string) error {
+	sqlStatement := `UPDATE workout SET name = $2, description = $3 WHERE workout_uid = $1`
+
+	result, err := db.ExecContext(ctx, sqlStatement, id, name, description)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("error during update of db")
+		return err
+	}
+	return ensureRowsAffected(ctx, result)
+}
+
+func (c *client) DeleteWorkout(ctx context.Context, id string) error {
+	log := logger.FromContext(ctx)
+
+	sqlStatement := `DELETE FROM workout WHERE workout_uid = $1`
+
+	_, err := c.db.ExecContext(ctx, sqlStatement, id)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+
+	return nil
+}
+
+func (c *client) UpdateWorkoutPart(ctx context.Context, workoutId, parentId string, order, distance int, metric, intensityId string, repeat int) (models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		sqlStatement :=
+			`UPDATE workout_parts SET distance = $4, metric = $5, intensity_uid = $6, repeat = $7
+				WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid AND "order" = $3`
+
+		result, err := tx.ExecContext(ctx, sqlStatement,
+			workoutId, nullString(parentId), order, distance, nullString(metric), nullString(intensityId), nullInt(repeat))
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+		err = ensureRowsAffected(ctx, result)
+		if err != nil {
+			return err
+		}
+
+		return checkWorkoutParts(ctx, tx, workoutId)
+	})
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	return c.GetWorkout(ctx, workoutId)
+}
+
+// RemoveWorkoutPart deletes a part and moves the following parts one step forward.
+// Removing a repeat block also removes the parts inside it, and removing the last part of a repeat block
+// removes the block, so no block is left empty.
+func (c *client) RemoveWorkoutPart(ctx context.Context, workoutId, parentId string, order int) (models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		for {
+			err := removeWorkoutPart(ctx, tx, workoutId, parentId, order)
+			if err != nil {
+				return err
+			}
+			if parentId == "" {
+				return nil
+			}
+
+			var empty bool
+			var grandparentId sql.NullString
+			err = tx.QueryRowContext(ctx,
+				`SELECT NOT EXISTS (SELECT 1 FROM workout_parts WHERE parent_uid = p.part_uid),
+						p.parent_uid::text, p."order"
+					FROM workout_parts AS p WHERE p.part_uid = $1`,
+				parentId).Scan(&empty, &grandparentId, &order)
+			if err != nil {
+				log.WithError(err).Error("Error while parsing db row")
+				return err
+			}
+			if !empty {
+				return nil
+			}
+			parentId = grandparentId.String
+		}
+	})
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	return c.GetWorkout(ctx, workoutId)
+}
+
+func removeWorkoutPart(ctx context.Context, tx *sql.Tx, workoutId, parentId string, order int) error {
+	log := logger.FromContext(ctx)
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM workout_parts
+			WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid AND "order" = $3`,
+		workoutId, nullString(parentId), order)
+	if err != nil {
+		log.WithError(err).Error("error during delete from db")
+		return err
+	}
+	err = ensureRowsAffected(ctx, result)
+	if err != nil {
+		return err
+	}
+
+	// The order index is checked for every row, so the parts are moved through negative orders
+	// to avoid colliding with each other while they are renumbered.
+	_, err = tx.ExecContext(ctx,
+		`UPDATE workout_parts SET "order" = -"order"
+			WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid AND "order" > $3`,
+		workoutId, nullString(parentId), order)
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE workout_parts SET "order" = -"order" - 1
+			WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid AND "order" < 0`,
+		workoutId, nullString(parentId))
+	if err != nil {
+		log.WithError(err).Error("error during update of db")
+		return err
 	}
+	return nil
+}
+
+// ReorderWorkoutParts renumbers the parts of a workout, or of the repeat block given by parentId.
+// orders lists the current orders of the parts in their new sequence, so [2, 0, 1] moves the third part first.
+func (c *client) ReorderWorkoutParts(ctx context.Context, workoutId, parentId string, orders []int) (models.Workout, error) {
+	log := logger.FromContext(ctx)
+
+	err := c.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT "order" FROM workout_parts WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid`,
+			workoutId, nullString(parentId))
+		if err != nil {
+			log.WithError(err).Error("Error querying db")
+			return err
+		}
+		existing := make(map[int]bool)
+		for rows.Next() {
+			var order int
+			err = rows.Scan(&order)
+			if err != nil {
+				log.WithError(err).Error("Error while parsing db row")
+				_ = rows.Close()
+				return err
+			}
+			existing[order] = true
+		}
+		err = rows.Close()
+		if err != nil {
+			log.WithError(err).Error("Error closing db query connection")
+			return err
+		}
 
-	sort.Slice(workoutParts, func(i, j int) bool {
-		return workoutParts[i].Order < workoutParts[j].Order
+		if len(orders) != len(existing) {
+			return ErrInvalidPartOrder
+		}
+		for _, order := range orders {
+			if !existing[order] {
+				return ErrInvalidPartOrder
+			}
+			delete(existing, order)
+		}
+
+		// Move every part to a negative order first, so the order index never collides while renumbering.
+		_, err = tx.ExecContext(ctx,
+			`UPDATE workout_parts SET "order" = -"order" - 1
+				WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid`,
+			workoutId, nullString(parentId))
+		if err != nil {
+			log.WithError(err).Error("error during update of db")
+			return err
+		}
+		for newOrder, oldOrder := range orders {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE workout_parts SET "order" = $4
+					WHERE workout_uid = $1 AND parent_uid IS NOT DISTINCT FROM $2::uuid AND "order" = $3`,
+				workoutId, nullString(parentId), -oldOrder-1, newOrder)
+			if err != nil {
+				log.WithError(err).Error("error during update of db")
+				return err
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return models.Workout{}, err
+	}
 
-	return workoutParts, nil
+	return c.GetWorkout(ctx, workoutId)
 }
@@ -0,0 +1,110 @@
+// Package estimates estimates how long and how far workouts are from the training paces of a VDOT.
+package estimates
+
+import (
+	"goapi/models"
+	"goapi/vdot"
+	"strings"
+)
+
+const (
+	metricMeter  = "meter"
+	metricSecond = "second"
+
+	tenKMeters = 10000.0
+)
+
+// Estimate is the estimated duration in seconds and distance in meters of a part of a workout.
+type Estimate struct {
+	Seconds float64
+	Meters  float64
+}
+
+// Add sums up two estimates.
+func (e Estimate) Add(other Estimate) Estimate {
+	return Estimate{Seconds: e.Seconds + other.Seconds, Meters: e.Meters + other.Meters}
+}
+
+// Times multiplies an estimate, like the parts of a repeat block.
+func (e Estimate) Times(count int) Estimate {
+	return Estimate{Seconds: e.Seconds * float64(count), Meters: e.Meters * float64(count)}
+}
+
+// Estimator estimates workout parts from the training paces of a VDOT.
+type Estimator struct {
+	vdot  float64
+	paces map[string]vdot.Pace
+}
+
+// New creates an estimator for the VDOT. An estimator for a VDOT of 0 or less estimates nothing.
+func New(value float64) Estimator {
+	if value <= 0 {
+		return Estimator{}
+	}
+
+	paces := vdot.TrainingPaces(value)
+	tenK := vdot.RaceTime(value, tenKMeters) / (tenKMeters / 1000)
+	return Estimator{vdot: value, paces: map[string]vdot.Pace{
+		"easy":       paces.Easy,
+		"marathon":   paces.Marathon,
+		"threshold":  paces.Threshold,
+		"10k":        {Fastest: tenK, Slowest: tenK},
+		"interval":   paces.Interval,
+		"repetition": paces.Repetition,
+	}}
+}
+
+// TargetPace is the pace range, in seconds per kilometer, of an intensity. Intensities with a VDOT band get
+// the paces of the band. Other intensities are matched by name with the training intensities of the VDOT
+// model, and the second return value is false when there is no match.
+func (e Estimator) TargetPace(intensity models.Intensity) (vdot.Pace, bool) {
+	if band := intensity.Targets.Vdot; band.IsSet() && e.vdot > 0 {
+		return vdot.PaceBand(e.vdot, band.Min/100, band.Max/100), true
+	}
+	pace, ok := e.paces[strings.ToLower(strings.TrimSpace(intensity.Name))]
+	return pace, ok
+}
+
+// Part estimates a part of a workout, including all repetitions of a repeat block. The second return value is
+// false when the pace of any of the intensities is unknown.
+func (e Estimator) Part(part models.WorkoutPart) (Estimate, bool) {
+	if part.IsRepeat() {
+		estimate, ok := e.Parts(part.Parts)
+		return estimate.Times(part.Repeat), ok
+	}
+
+	pace, ok := e.TargetPace(part.Intensity)
+	if !ok || pace.Average() <= 0 {
+		return Estimate{}, false
+	}
+
+	amount := float64(part.Distance)
+	switch part.Metric {
+	case metricSecond:
+		return Estimate{Seconds: amount, Meters: amount / pace.Average() * 1000}, true
+	case metricMeter:
+		return Estimate{Seconds: amount / 1000 * pace.Average(), Meters: amount}, true
+	default:
+		return Estimate{}, false
+	}
+}
+
+// Parts estimates a list of parts, like all the parts of a workout.
+func (e Estimator) Parts(parts []models.WorkoutPart) (Estimate, bool) {
+	total := Estimate{}
+	for _, part := range parts {
+		estimate, ok := e.Part(part)
+		if !ok {
+			return Estimate{}, false
+		}
+		total = total.Add(estimate)
+	}
+	return total, true
+}
+
+// ThresholdPace is the threshold pace of the VDOT, which relative intensities are measured against. The second
+// return value is false for an estimator without a VDOT.
+func (e Estimator) ThresholdPace() (vdot.Pace, bool) {
+	pace, ok := e.paces["threshold"]
+	return pace, ok
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "erg", Extension: "erg", ContentType: "text/plain; charset=UTF-8", Write: writeERG})
+	register(Format{Name: "mrc", Extension: "mrc", ContentType: "text/plain; charset=UTF-8", Write: writeMRC})
+}
+
+// writeERG writes the steps as watts over minutes. The watts are the power target of the step, or the level of
+// the step times the threshold power of the athlete.
+func writeERG(workout Workout) ([]byte, error) {
+	return writeCourse(workout, "WATTS", func(step Step) (float64, bool) {
+		if step.Power != nil {
+			return (step.Power.Min + step.Power.Max) / 2, true
+		}
+		if workout.ThresholdPower > 0 && step.Level > 0 {
+			return step.Level * float64(workout.ThresholdPower), true
+		}
+		return 0, false
+	})
+}
+
+// writeMRC writes the steps as percentages of threshold over minutes.
+func writeMRC(workout Workout) ([]byte, error) {
+	return writeCourse(workout, "PERCENT", func(step Step) (float64, bool) {
+		return step.Level * 100, step.Level > 0
+	})
+}
+
+// writeCourse writes the course format that ERG and MRC files share, where every step is a flat segment from
+// its start to its end minute. Every step needs a duration and a value.
+func writeCourse(workout Workout, unit string, value func(Step) (float64, bool)) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("[COURSE HEADER]\r\n")
+	buffer.WriteString("VERSION = 2\r\n")
+	buffer.WriteString("UNITS = METRIC\r\n")
+	fmt.Fprintf(&buffer, "DESCRIPTION = %s\r\n", singleLine(workout.Description))
+	fmt.Fprintf(&buffer, "FILE NAME = %s\r\n", singleLine(workout.Name))
+	if unit == "WATTS" && workout.ThresholdPower > 0 {
+		fmt.Fprintf(&buffer, "FTP = %d\r\n", workout.ThresholdPower)
+	}
+	fmt.Fprintf(&buffer, "MINUTES %s\r\n", unit)
+	buffer.WriteString("[END COURSE HEADER]\r\n")
+	buffer.WriteString("[COURSE DATA]\r\n")
+
+	minutes := 0.0
+	for _, step := range flatten(workout.Steps) {
+		seconds, ok := step.Seconds()
+		if !ok {
+			return nil, ErrNotExportable
+		}
+		level, ok := value(step)
+		if !ok {
+			return nil, ErrNotExportable
+		}
+		fmt.Fprintf(&buffer, "%.2f\t%d\r\n", minutes, round(level))
+		minutes += seconds / 60
+		fmt.Fprintf(&buffer, "%.2f\t%d\r\n", minutes, round(level))
+	}
+	buffer.WriteString("[END COURSE DATA]\r\n")
+	return buffer.Bytes(), nil
+}
+
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,203 @@
+// Package export writes workouts in the file formats of watches, treadmills and indoor training apps. A workout
+// is first built into steps with absolute targets for one athlete, and each format then writes the steps the
+// way it supports them.
+package export
+
+import (
+	"errors"
+	"goapi/estimates"
+	"goapi/models"
+	"goapi/targets"
+	"goapi/vdot"
+	"sort"
+	"strings"
+)
+
+const (
+	metricMeter  = "meter"
+	metricSecond = "second"
+)
+
+// ErrNotExportable is returned by formats that need durations or targets that the workout does not have.
+var ErrNotExportable = errors.New("the workout can not be written in this format")
+
+// Kinds of steps, which watches and apps show differently.
+const (
+	KindActive   = "active"
+	KindWarmup   = "warmup"
+	KindCooldown = "cooldown"
+	KindRest     = "rest"
+)
+
+// Duration types of steps.
+const (
+	DurationTime     = "time"
+	DurationDistance = "distance"
+	DurationOpen     = "open"
+)
+
+// Workout is a workout with the targets of one athlete.
+type Workout struct {
+	Name        string
+	Description string
+	Steps       []Step
+	// ThresholdPower is the threshold power of the athlete in watts, or 0 if it is unknown.
+	ThresholdPower int
+}
+
+// Step is a step of a workout, or a block of steps that is repeated when Repeat is more than 0.
+type Step struct {
+	Name string
+	Kind string
+	// Duration is in seconds for time steps and in meters for distance steps.
+	DurationType string
+	Duration     float64
+	// Estimate is the duration and distance of the step at the target pace, if the pace is known.
+	Estimate  estimates.Estimate
+	Estimated bool
+
+	// Pace is in seconds per kilometer, heart rate in beats per minute and power in watts. Nil targets are unknown.
+	Pace      *vdot.Pace
+	HeartRate *models.Range
+	Power     *models.Range
+	// Level is the intensity as a fraction of threshold, from the power target or the pace. It is 0 when
+	// neither is known.
+	Level float64
+
+	Repeat int
+	Steps  []Step
+}
+
+// Build resolves the parts of a workout to steps with the paces and heart rates of the profile.
+func Build(workout models.Workout, parts []models.WorkoutPart, profile models.Profile, estimator estimates.Estimator) Workout {
+	return Workout{
+		Name:           workout.Name,
+		Description:    workout.Description,
+		Steps:          buildSteps(parts, profile, estimator),
+		ThresholdPower: profile.ThresholdPower,
+	}
+}
+
+func buildSteps(parts []models.WorkoutPart, profile models.Profile, estimator estimates.Estimator) []Step {
+	threshold, hasThreshold := estimator.ThresholdPace()
+
+	steps := []Step{}
+	for _, part := range parts {
+		if part.IsRepeat() {
+			// A repeat block is empty until parts are added to it, and has nothing to export until then.
+			if inner := buildSteps(part.Parts, profile, estimator); len(inner) > 0 {
+				steps = append(steps, Step{Repeat: part.Repeat, Steps: inner})
+			}
+			continue
+		}
+
+		step := Step{
+			Name:         part.Intensity.Name,
+			Kind:         kindOf(part.Intensity),
+			DurationType: DurationOpen,
+		}
+		switch part.Metric {
+		case metricSecond:
+			step.DurationType, step.Duration = DurationTime, float64(part.Distance)
+		case metricMeter:
+			step.DurationType, step.Duration = DurationDistance, float64(part.Distance)
+		}
+		step.Estimate, step.Estimated = estimator.Part(part)
+
+		if pace, ok := estimator.TargetPace(part.Intensity); ok && pace.Fastest > 0 && pace.Slowest > 0 {
+			step.Pace = &pace
+			if hasThreshold {
+				step.Level = threshold.Average() / pace.Average()
+			}
+		}
+		if heartRate, ok := targets.HeartRate(part.Intensity.Targets, profile); ok {
+			step.HeartRate = &heartRate
+		}
+		if power, ok := targets.Power(part.Intensity.Targets, profile); ok {
+			step.Power = &power
+		}
+		if band := part.Intensity.Targets.Power; band.IsSet() {
+			step.Level = (band.Min + band.Max) / 2 / 100
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// kindOf recognizes warm-ups, cool-downs and recoveries by the name of the intensity.
+func kindOf(intensity models.Intensity) string {
+	name := strings.ToLower(intensity.Name)
+	switch {
+	case strings.Contains(name, "warm"):
+		return KindWarmup
+	case strings.Contains(name, "cool"):
+		return KindCooldown
+	case strings.Contains(name, "rest"), strings.Contains(name, "recovery"):
+		return KindRest
+	default:
+		return KindActive
+	}
+}
+
+// Seconds is the duration of a step, or its estimate for distance steps. The second return value is false when
+// the duration is unknown.
+func (s Step) Seconds() (float64, bool) {
+	switch {
+	case s.DurationType == DurationTime:
+		return s.Duration, true
+	case s.Estimated:
+		return s.Estimate.Seconds, true
+	default:
+		return 0, false
+	}
+}
+
+// flatten expands the repeat blocks into the plain steps they consist of, in the order they are run. Like
+// models.FlattenWorkoutParts, it stops after models.MaxWorkoutSteps steps.
+func flatten(steps []Step) []Step {
+	flat := []Step{}
+	for _, step := range steps {
+		if step.Repeat > 0 {
+			inner := flatten(step.Steps)
+			for i := 0; i < step.Repeat && len(inner) > 0 && len(flat) < models.MaxWorkoutSteps; i++ {
+				flat = append(flat, inner...)
+			}
+		} else {
+			flat = append(flat, step)
+		}
+		if len(flat) >= models.MaxWorkoutSteps {
+			return flat[:models.MaxWorkoutSteps]
+		}
+	}
+	return flat
+}
+
+// Format is a file format that workouts can be exported to.
+type Format struct {
+	Name        string
+	Extension   string
+	ContentType string
+	Write       func(Workout) ([]byte, error)
+}
+
+var formats = map[string]Format{}
+
+func register(format Format) {
+	formats[format.Name] = format
+}
+
+// FormatByName finds a format by its name, like zwo or json.
+func FormatByName(name string) (Format, bool) {
+	format, ok := formats[strings.ToLower(name)]
+	return format, ok
+}
+
+// FormatNames lists the names of all the formats.
+func FormatNames() []string {
+	names := []string{}
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
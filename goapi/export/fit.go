@@ -0,0 +1,60 @@
+package export
+
+import (
+	"goapi/fit"
+	"time"
+)
+
+func init() {
+	register(Format{Name: "fit", Extension: "fit", ContentType: "application/vnd.ant.fit", Write: writeFIT})
+}
+
+var fitKinds = map[string]fit.StepIntensity{
+	KindActive:   fit.IntensityActive,
+	KindWarmup:   fit.IntensityWarmup,
+	KindCooldown: fit.IntensityCooldown,
+	KindRest:     fit.IntensityRest,
+}
+
+// writeFIT writes a FIT workout file. Steps target their pace, or their heart rate when the pace is unknown.
+func writeFIT(workout Workout) ([]byte, error) {
+	return fit.EncodeWorkout(fit.Workout{
+		Name:  workout.Name,
+		Steps: fitSteps(workout.Steps),
+	}, time.Now()), nil
+}
+
+func fitSteps(steps []Step) []fit.WorkoutStep {
+	converted := []fit.WorkoutStep{}
+	for _, step := range steps {
+		if step.Repeat > 0 {
+			if inner := fitSteps(step.Steps); len(inner) > 0 {
+				converted = append(converted, fit.WorkoutStep{Repeat: step.Repeat, Steps: inner})
+			}
+			continue
+		}
+
+		fitStep := fit.WorkoutStep{
+			Name:         step.Name,
+			DurationType: fit.DurationOpen,
+			TargetType:   fit.TargetOpen,
+			Intensity:    fitKinds[step.Kind],
+		}
+		switch step.DurationType {
+		case DurationTime:
+			fitStep.DurationType, fitStep.Duration = fit.DurationTime, step.Duration
+		case DurationDistance:
+			fitStep.DurationType, fitStep.Duration = fit.DurationDistance, step.Duration
+		}
+		if step.Pace != nil {
+			// Paces are in seconds per kilometer, so the slowest pace is the lowest speed.
+			fitStep.TargetType = fit.TargetSpeed
+			fitStep.TargetLow, fitStep.TargetHigh = 1000/step.Pace.Slowest, 1000/step.Pace.Fastest
+		} else if step.HeartRate != nil {
+			fitStep.TargetType = fit.TargetHeartRate
+			fitStep.TargetLow, fitStep.TargetHigh = step.HeartRate.Min, step.HeartRate.Max
+		}
+		converted = append(converted, fitStep)
+	}
+	return converted
+}
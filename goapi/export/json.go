@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+)
+
+// The JSON step format is a stable format for other tools to read our workouts. New fields may be added, but
+// the fields below keep their meaning for as long as the version is 1.
+//
+//	{
+//	  "format": "treningsplan-workout",
+//	  "version": 1,
+//	  "name": "5 x 1000 m",
+//	  "description": "Intervals at 10k pace",
+//	  "steps": [
+//	    {
+//	      "type": "step",
+//	      "name": "Easy",
+//	      "kind": "warmup",                                  // active, warmup, cooldown or rest
+//	      "duration": {"type": "time", "value": 900},        // time in seconds, distance in meters, or open
+//	      "estimatedSeconds": 900,                           // left out when the pace is unknown
+//	      "estimatedMeters": 2700,
+//	      "pace": {"fastest": 300, "slowest": 330},          // seconds per kilometer, left out when unknown
+//	      "heartRate": {"min": 120, "max": 140},             // beats per minute, left out when unknown
+//	      "power": {"min": 200, "max": 230}                  // watts, left out when unknown
+//	    },
+//	    {
+//	      "type": "repeat",
+//	      "count": 5,
+//	      "steps": [...]
+//	    }
+//	  ]
+//	}
+//
+// Values are rounded to whole numbers.
+const (
+	jsonFormatName    = "treningsplan-workout"
+	jsonFormatVersion = 1
+)
+
+func init() {
+	register(Format{Name: "json", Extension: "json", ContentType: "application/json; charset=UTF-8", Write: writeJSON})
+}
+
+type jsonWorkout struct {
+	Format      string     `json:"format"`
+	Version     int        `json:"version"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Steps       []jsonStep `json:"steps"`
+}
+
+type jsonStep struct {
+	Type string `json:"type"`
+
+	Name             string        `json:"name,omitempty"`
+	Kind             string        `json:"kind,omitempty"`
+	Duration         *jsonDuration `json:"duration,omitempty"`
+	EstimatedSeconds *int          `json:"estimatedSeconds,omitempty"`
+	EstimatedMeters  *int          `json:"estimatedMeters,omitempty"`
+	Pace             *jsonPace     `json:"pace,omitempty"`
+	HeartRate        *jsonRange    `json:"heartRate,omitempty"`
+	Power            *jsonRange    `json:"power,omitempty"`
+
+	Count int        `json:"count,omitempty"`
+	Steps []jsonStep `json:"steps,omitempty"`
+}
+
+type jsonDuration struct {
+	Type  string `json:"type"`
+	Value int    `json:"value,omitempty"`
+}
+
+type jsonPace struct {
+	Fastest int `json:"fastest"`
+	Slowest int `json:"slowest"`
+}
+
+type jsonRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func writeJSON(workout Workout) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(jsonWorkout{
+		Format:      jsonFormatName,
+		Version:     jsonFormatVersion,
+		Name:        workout.Name,
+		Description: workout.Description,
+		Steps:       jsonSteps(workout.Steps),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func jsonSteps(steps []Step) []jsonStep {
+	converted := []jsonStep{}
+	for _, step := range steps {
+		if step.Repeat > 0 {
+			converted = append(converted, jsonStep{Type: "repeat", Count: step.Repeat, Steps: jsonSteps(step.Steps)})
+			continue
+		}
+
+		jsonStep := jsonStep{
+			Type:     "step",
+			Name:     step.Name,
+			Kind:     step.Kind,
+			Duration: &jsonDuration{Type: step.DurationType, Value: round(step.Duration)},
+		}
+		if step.Estimated {
+			seconds, meters := round(step.Estimate.Seconds), round(step.Estimate.Meters)
+			jsonStep.EstimatedSeconds, jsonStep.EstimatedMeters = &seconds, &meters
+		}
+		if step.Pace != nil {
+			jsonStep.Pace = &jsonPace{Fastest: round(step.Pace.Fastest), Slowest: round(step.Pace.Slowest)}
+		}
+		if step.HeartRate != nil {
+			jsonStep.HeartRate = &jsonRange{Min: round(step.HeartRate.Min), Max: round(step.HeartRate.Max)}
+		}
+		if step.Power != nil {
+			jsonStep.Power = &jsonRange{Min: round(step.Power.Min), Max: round(step.Power.Max)}
+		}
+		converted = append(converted, jsonStep)
+	}
+	return converted
+}
+
+func round(value float64) int {
+	return int(math.Round(value))
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(Format{Name: "txt", Extension: "txt", ContentType: "text/plain; charset=UTF-8", Write: writeText})
+}
+
+// writeText writes the workout as plain text, with a line per step and the steps of repeat blocks indented.
+func writeText(workout Workout) ([]byte, error) {
+	text := workout.Name + "\n"
+	if workout.Description != "" {
+		text += workout.Description + "\n"
+	}
+	return []byte(text + "\n" + Text(workout.Steps)), nil
+}
+
+// Text describes steps in plain text, like "1 km Threshold 4:15/km".
+func Text(steps []Step) string {
+	var builder strings.Builder
+	writeSteps(&builder, steps, "")
+	return builder.String()
+}
+
+func writeSteps(builder *strings.Builder, steps []Step, indent string) {
+	for _, step := range steps {
+		if step.Repeat > 0 {
+			fmt.Fprintf(builder, "%s%d x\n", indent, step.Repeat)
+			writeSteps(builder, step.Steps, indent+"  ")
+			continue
+		}
+
+		parts := []string{}
+		switch step.DurationType {
+		case DurationTime:
+			parts = append(parts, clock(step.Duration))
+		case DurationDistance:
+			parts = append(parts, distance(step.Duration))
+		}
+		if step.Name != "" {
+			parts = append(parts, step.Name)
+		}
+		switch {
+		case step.Pace != nil:
+			parts = append(parts, paceRange(step.Pace.Fastest, step.Pace.Slowest))
+		case step.HeartRate != nil:
+			parts = append(parts, fmt.Sprintf("%d-%d bpm", round(step.HeartRate.Min), round(step.HeartRate.Max)))
+		case step.Power != nil:
+			parts = append(parts, fmt.Sprintf("%d-%d W", round(step.Power.Min), round(step.Power.Max)))
+		}
+		builder.WriteString(indent + strings.Join(parts, " ") + "\n")
+	}
+}
+
+// clock formats seconds as h:mm:ss, or m:ss when it is less than an hour.
+func clock(seconds float64) string {
+	total := round(seconds)
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// distance formats meters, switching to kilometers from 1000 meters.
+func distance(meters float64) string {
+	if meters < 1000 {
+		return strconv.Itoa(round(meters)) + " m"
+	}
+	return strconv.FormatFloat(math.Round(meters/100)/10, 'f', -1, 64) + " km"
+}
+
+func paceRange(fastest, slowest float64) string {
+	if round(fastest) == round(slowest) {
+		return clock(fastest) + "/km"
+	}
+	return clock(fastest) + "-" + clock(slowest) + "/km"
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"encoding/xml"
+	"math"
+)
+
+func init() {
+	register(Format{Name: "zwo", Extension: "zwo", ContentType: "application/xml; charset=UTF-8", Write: writeZWO})
+}
+
+type zwoFile struct {
+	XMLName     xml.Name     `xml:"workout_file"`
+	Author      string       `xml:"author"`
+	Name        string       `xml:"name"`
+	Description string       `xml:"description"`
+	SportType   string       `xml:"sportType"`
+	Segments    []zwoSegment `xml:"workout>x"`
+}
+
+// zwoSegment is one of the SteadyState, FreeRide and IntervalsT elements, told apart by the XML name.
+type zwoSegment struct {
+	XMLName     xml.Name
+	Duration    int     `xml:"Duration,attr,omitempty"`
+	Power       float64 `xml:"Power,attr,omitempty"`
+	Repeat      int     `xml:"Repeat,attr,omitempty"`
+	OnDuration  int     `xml:"OnDuration,attr,omitempty"`
+	OffDuration int     `xml:"OffDuration,attr,omitempty"`
+	OnPower     float64 `xml:"OnPower,attr,omitempty"`
+	OffPower    float64 `xml:"OffPower,attr,omitempty"`
+}
+
+// writeZWO writes a Zwift running workout. Zwift measures all steps in time, so distance steps need an estimated
+// duration, and the intensity of a step is its speed as a fraction of threshold. Repeat blocks of two steps
+// become intervals, and other repeat blocks are written out step by step.
+func writeZWO(workout Workout) ([]byte, error) {
+	segments, err := zwoSegments(workout.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := xml.MarshalIndent(zwoFile{
+		Author:      "S33 Treningsplan",
+		Name:        workout.Name,
+		Description: workout.Description,
+		SportType:   "run",
+		Segments:    segments,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func zwoSegments(steps []Step) ([]zwoSegment, error) {
+	segments := []zwoSegment{}
+	for _, step := range steps {
+		interval, ok, err := intervalOf(step)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			segments = append(segments, interval)
+			continue
+		}
+
+		for _, step := range flatten([]Step{step}) {
+			segment, err := segmentOf(step)
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, segment)
+		}
+	}
+	return segments, nil
+}
+
+// intervalOf writes a repeat block of two steps with targets as intervals. The second return value is false for
+// other steps.
+func intervalOf(step Step) (zwoSegment, bool, error) {
+	if step.Repeat == 0 || len(step.Steps) != 2 || step.Steps[0].Repeat > 0 || step.Steps[1].Repeat > 0 {
+		return zwoSegment{}, false, nil
+	}
+	on, err := segmentOf(step.Steps[0])
+	if err != nil {
+		return zwoSegment{}, false, err
+	}
+	off, err := segmentOf(step.Steps[1])
+	if err != nil {
+		return zwoSegment{}, false, err
+	}
+	if on.Power == 0 || off.Power == 0 {
+		return zwoSegment{}, false, nil
+	}
+
+	return zwoSegment{
+		XMLName:     xml.Name{Local: "IntervalsT"},
+		Repeat:      step.Repeat,
+		OnDuration:  on.Duration,
+		OffDuration: off.Duration,
+		OnPower:     on.Power,
+		OffPower:    off.Power,
+	}, true, nil
+}
+
+// segmentOf writes a step as a steady state, or as free running when the step has no pace or power.
+func segmentOf(step Step) (zwoSegment, error) {
+	seconds, ok := step.Seconds()
+	if !ok {
+		return zwoSegment{}, ErrNotExportable
+	}
+	if step.Level <= 0 {
+		return zwoSegment{XMLName: xml.Name{Local: "FreeRide"}, Duration: round(seconds)}, nil
+	}
+	return zwoSegment{
+		XMLName:  xml.Name{Local: "SteadyState"},
+		Duration: round(seconds),
+		Power:    math.Round(step.Level*1000) / 1000,
+	}, nil
+}
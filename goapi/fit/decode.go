@@ -0,0 +1,249 @@
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	headerMinSize = 12
+	crcSize       = 2
+
+	headerCompressedTimestamp = 0x80
+	headerDefinition          = 0x40
+	headerDeveloperData       = 0x20
+	localTypeMask             = 0x0f
+	compressedLocalTypeMask   = 0x60
+	compressedTimeOffsetMask  = 0x1f
+)
+
+// ErrNotFIT is returned for data that does not start with a FIT file header.
+var ErrNotFIT = errors.New("the file is not a FIT file")
+
+// ErrChecksum is returned when the data of a FIT file does not match its checksum.
+var ErrChecksum = errors.New("the FIT file is corrupt, its checksum does not match")
+
+// errTruncated is returned when a message runs past the end of the data.
+var errTruncated = errors.New("the FIT file is truncated")
+
+type fieldDefinition struct {
+	number   byte
+	size     int
+	baseType byte
+}
+
+type definition struct {
+	global    int
+	byteOrder binary.ByteOrder
+	fields    []fieldDefinition
+	// developerSize is the number of bytes of developer fields, which are skipped.
+	developerSize int
+}
+
+type decoder struct {
+	data        []byte
+	position    int
+	definitions [localTypeMask + 1]*definition
+	// timestamp is the last timestamp that was read, which compressed timestamps are relative to.
+	timestamp int64
+}
+
+// IsFIT tells whether the data starts with a FIT file header.
+func IsFIT(data []byte) bool {
+	return len(data) >= headerMinSize && int(data[0]) >= headerMinSize && string(data[8:12]) == ".FIT"
+}
+
+// Decode reads the sessions, laps and records of a FIT file. Chained FIT files, which some devices write for
+// multisport activities, are read one after the other.
+func Decode(data []byte) (File, error) {
+	if !IsFIT(data) {
+		return File{}, ErrNotFIT
+	}
+
+	var file File
+	for len(data) > 0 {
+		size, err := decodeFile(data, &file)
+		if err != nil {
+			return File{}, err
+		}
+		data = data[size:]
+	}
+	return file, nil
+}
+
+// decodeFile reads one FIT file from the start of the data, and returns how many bytes it takes up.
+func decodeFile(data []byte, file *File) (int, error) {
+	if !IsFIT(data) {
+		return 0, ErrNotFIT
+	}
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if len(data) < end+crcSize {
+		return 0, errTruncated
+	}
+	if checksum(data[:end]) != binary.LittleEndian.Uint16(data[end:end+crcSize]) {
+		return 0, ErrChecksum
+	}
+
+	d := decoder{data: data[headerSize:end]}
+	for d.position < len(d.data) {
+		err := d.next(file)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return end + crcSize, nil
+}
+
+func (d *decoder) read(size int) ([]byte, error) {
+	if d.position+size > len(d.data) {
+		return nil, errTruncated
+	}
+	bytes := d.data[d.position : d.position+size]
+	d.position += size
+	return bytes, nil
+}
+
+// next reads a definition or a data message.
+func (d *decoder) next(file *File) error {
+	header, err := d.read(1)
+	if err != nil {
+		return err
+	}
+
+	if header[0]&headerCompressedTimestamp != 0 {
+		local := (header[0] & compressedLocalTypeMask) >> 5
+		offset := int64(header[0] & compressedTimeOffsetMask)
+		timestamp := d.timestamp&^compressedTimeOffsetMask + offset
+		if offset < d.timestamp&compressedTimeOffsetMask {
+			timestamp += compressedTimeOffsetMask + 1
+		}
+		return d.readMessage(file, local, timestamp)
+	}
+
+	local := header[0] & localTypeMask
+	if header[0]&headerDefinition != 0 {
+		return d.readDefinition(local, header[0]&headerDeveloperData != 0)
+	}
+	return d.readMessage(file, local, -1)
+}
+
+func (d *decoder) readDefinition(local byte, hasDeveloperData bool) error {
+	bytes, err := d.read(5)
+	if err != nil {
+		return err
+	}
+
+	def := definition{byteOrder: binary.ByteOrder(binary.LittleEndian)}
+	if bytes[1] == 1 {
+		def.byteOrder = binary.BigEndian
+	}
+	def.global = int(def.byteOrder.Uint16(bytes[2:4]))
+
+	fields, err := d.read(3 * int(bytes[4]))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fieldDefinition{
+			number:   fields[i],
+			size:     int(fields[i+1]),
+			baseType: fields[i+2],
+		})
+	}
+
+	if hasDeveloperData {
+		count, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		developerFields, err := d.read(3 * int(count[0]))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(developerFields); i += 3 {
+			def.developerSize += int(developerFields[i+1])
+		}
+	}
+
+	d.definitions[local] = &def
+	return nil
+}
+
+// readMessage reads a data message. A timestamp of -1 means the message has no compressed timestamp header.
+func (d *decoder) readMessage(file *File, local byte, timestamp int64) error {
+	def := d.definitions[local]
+	if def == nil {
+		return fmt.Errorf("the FIT file uses local message type %d before defining it", local)
+	}
+
+	m := message{global: def.global, fields: map[byte]int64{}}
+	for _, field := range def.fields {
+		bytes, err := d.read(field.size)
+		if err != nil {
+			return err
+		}
+		if value, valid := decodeValue(bytes, field.baseType, def.byteOrder); valid {
+			m.fields[field.number] = value
+		}
+	}
+	_, err := d.read(def.developerSize)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := m.fields[fieldTimestamp]; ok {
+		d.timestamp = value
+	} else if timestamp >= 0 {
+		m.fields[fieldTimestamp] = timestamp
+		d.timestamp = timestamp
+	}
+
+	file.add(m)
+	return nil
+}
+
+// decodeValue reads the first value of an integer field. Values that mark the field as invalid, strings,
+// floats and fields of the wrong size are not valid.
+func decodeValue(bytes []byte, baseType byte, byteOrder binary.ByteOrder) (int64, bool) {
+	var size int
+	var value uint64
+	switch baseType {
+	case baseEnum, baseUint8, baseUint8z, baseSint8, baseByte:
+		size = 1
+	case baseUint16, baseUint16z, baseSint16:
+		size = 2
+	case baseUint32, baseUint32z, baseSint32:
+		size = 4
+	default:
+		return 0, false
+	}
+	if len(bytes) < size {
+		return 0, false
+	}
+
+	switch size {
+	case 1:
+		value = uint64(bytes[0])
+	case 2:
+		value = uint64(byteOrder.Uint16(bytes))
+	case 4:
+		value = uint64(byteOrder.Uint32(bytes))
+	}
+	if value == invalidValues[baseType] {
+		return 0, false
+	}
+
+	switch baseType {
+	case baseSint8:
+		return int64(int8(value)), true
+	case baseSint16:
+		return int64(int16(value)), true
+	case baseSint32:
+		return int64(int32(value)), true
+	default:
+		return int64(value), true
+	}
+}
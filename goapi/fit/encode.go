@@ -0,0 +1,226 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	headerSize = 14
+	// protocolVersion is 1.0, since workout files need nothing newer and older watches reject newer versions.
+	protocolVersion = 0x10
+	profileVersion  = 2100
+
+	messageWorkout     = 26
+	messageWorkoutStep = 27
+
+	fileTypeWorkout         = 5
+	manufacturerDevelopment = 255
+	sportRunning            = 1
+
+	// Names are cut to fit the fixed size string fields.
+	maxNameBytes = 32
+)
+
+// DurationType tells when a workout step ends.
+type DurationType byte
+
+const (
+	DurationTime     DurationType = 0
+	DurationDistance DurationType = 1
+	DurationOpen     DurationType = 5
+	// DurationRepeat repeats the steps from an earlier step. It is added by EncodeWorkout for repeat blocks.
+	durationRepeat DurationType = 6
+)
+
+// TargetType tells what the athlete aims for during a workout step.
+type TargetType byte
+
+const (
+	TargetSpeed     TargetType = 0
+	TargetHeartRate TargetType = 1
+	TargetOpen      TargetType = 2
+	TargetPower     TargetType = 4
+)
+
+// StepIntensity tells the watch what kind of step it is, which changes how it is shown.
+type StepIntensity byte
+
+const (
+	IntensityActive   StepIntensity = 0
+	IntensityRest     StepIntensity = 1
+	IntensityWarmup   StepIntensity = 2
+	IntensityCooldown StepIntensity = 3
+)
+
+// Workout is a structured workout that can be loaded onto a watch.
+type Workout struct {
+	Name  string
+	Steps []WorkoutStep
+}
+
+// WorkoutStep is a step of a workout, or a block of steps that is repeated when Repeat is more than 0.
+type WorkoutStep struct {
+	Name         string
+	DurationType DurationType
+	// Duration is in seconds for time steps and in meters for distance steps.
+	Duration   float64
+	TargetType TargetType
+	// TargetLow and TargetHigh are in meters per second for speed targets, beats per minute for heart rate
+	// targets and watts for power targets.
+	TargetLow  float64
+	TargetHigh float64
+	Intensity  StepIntensity
+
+	Repeat int
+	Steps  []WorkoutStep
+}
+
+type workoutStepMessage struct {
+	name         string
+	durationType DurationType
+	duration     uint32
+	targetType   TargetType
+	targetValue  uint32
+	targetLow    uint32
+	targetHigh   uint32
+	intensity    StepIntensity
+}
+
+// flattenSteps numbers the steps the way FIT files store them, where the steps of a repeat block come first,
+// followed by a step that repeats them. Repeat blocks without steps are left out, since their repeat step
+// would repeat itself.
+func flattenSteps(steps []WorkoutStep, messages []workoutStepMessage) []workoutStepMessage {
+	for _, step := range steps {
+		if step.Repeat > 0 {
+			first := len(messages)
+			messages = flattenSteps(step.Steps, messages)
+			if len(messages) == first {
+				continue
+			}
+			messages = append(messages, workoutStepMessage{
+				durationType: durationRepeat,
+				duration:     uint32(first),
+				targetType:   TargetOpen,
+				targetValue:  uint32(step.Repeat),
+			})
+			continue
+		}
+
+		message := workoutStepMessage{
+			name:         step.Name,
+			durationType: step.DurationType,
+			targetType:   step.TargetType,
+			intensity:    step.Intensity,
+		}
+		// Durations are stored in milliseconds and distances in centimeters.
+		switch step.DurationType {
+		case DurationTime:
+			message.duration = uint32(step.Duration * 1000)
+		case DurationDistance:
+			message.duration = uint32(step.Duration * 100)
+		}
+		// A target value of 0 means that the custom range is used. Speeds are stored in millimeters per second,
+		// and heart rates and power are offset to tell them apart from zones.
+		switch step.TargetType {
+		case TargetSpeed:
+			message.targetLow, message.targetHigh = uint32(step.TargetLow*1000), uint32(step.TargetHigh*1000)
+		case TargetHeartRate:
+			message.targetLow, message.targetHigh = uint32(step.TargetLow)+100, uint32(step.TargetHigh)+100
+		case TargetPower:
+			message.targetLow, message.targetHigh = uint32(step.TargetLow)+1000, uint32(step.TargetHigh)+1000
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// EncodeWorkout writes a running workout as a FIT workout file.
+func EncodeWorkout(workout Workout, created time.Time) []byte {
+	steps := flattenSteps(workout.Steps, nil)
+
+	var body bytes.Buffer
+	e := encoder{buffer: &body}
+
+	e.define(0, messageFileId, []fieldDefinition{
+		{number: 0, size: 1, baseType: baseEnum},
+		{number: 1, size: 2, baseType: baseUint16},
+		{number: 4, size: 4, baseType: baseUint32},
+	})
+	e.data(0, byte(fileTypeWorkout), uint16(manufacturerDevelopment), fitTime(created))
+
+	e.define(1, messageWorkout, []fieldDefinition{
+		{number: 4, size: 1, baseType: baseEnum},
+		{number: 6, size: 2, baseType: baseUint16},
+		{number: 8, size: maxNameBytes, baseType: baseString},
+	})
+	e.data(1, byte(sportRunning), uint16(len(steps)), fixedString(workout.Name))
+
+	e.define(2, messageWorkoutStep, []fieldDefinition{
+		{number: 254, size: 2, baseType: baseUint16},
+		{number: 0, size: maxNameBytes, baseType: baseString},
+		{number: 1, size: 1, baseType: baseEnum},
+		{number: 2, size: 4, baseType: baseUint32},
+		{number: 3, size: 1, baseType: baseEnum},
+		{number: 4, size: 4, baseType: baseUint32},
+		{number: 5, size: 4, baseType: baseUint32},
+		{number: 6, size: 4, baseType: baseUint32},
+		{number: 7, size: 1, baseType: baseEnum},
+	})
+	for i, step := range steps {
+		e.data(2, uint16(i), fixedString(step.name), byte(step.durationType), step.duration, byte(step.targetType),
+			step.targetValue, step.targetLow, step.targetHigh, byte(step.intensity))
+	}
+
+	header := make([]byte, headerSize)
+	header[0] = headerSize
+	header[1] = protocolVersion
+	binary.LittleEndian.PutUint16(header[2:4], profileVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(body.Len()))
+	copy(header[8:12], ".FIT")
+	binary.LittleEndian.PutUint16(header[12:14], checksum(header[:12]))
+
+	file := append(header, body.Bytes()...)
+	crc := make([]byte, crcSize)
+	binary.LittleEndian.PutUint16(crc, checksum(file))
+	return append(file, crc...)
+}
+
+type encoder struct {
+	buffer *bytes.Buffer
+}
+
+// define writes a little endian definition message.
+func (e encoder) define(local byte, global uint16, fields []fieldDefinition) {
+	e.buffer.Write([]byte{headerDefinition | local, 0, 0})
+	_ = binary.Write(e.buffer, binary.LittleEndian, global)
+	e.buffer.WriteByte(byte(len(fields)))
+	for _, field := range fields {
+		e.buffer.Write([]byte{field.number, byte(field.size), field.baseType})
+	}
+}
+
+// data writes a data message with the values in the order of the definition.
+func (e encoder) data(local byte, values ...interface{}) {
+	e.buffer.WriteByte(local)
+	for _, value := range values {
+		_ = binary.Write(e.buffer, binary.LittleEndian, value)
+	}
+}
+
+func fitTime(t time.Time) uint32 {
+	return uint32(t.Sub(epoch) / time.Second)
+}
+
+// fixedString is a null terminated string field, cut at a character boundary if it is too long.
+func fixedString(value string) [maxNameBytes]byte {
+	var field [maxNameBytes]byte
+	for len(value) > maxNameBytes-1 {
+		_, size := utf8.DecodeLastRuneInString(value)
+		value = value[:len(value)-size]
+	}
+	copy(field[:], value)
+	return field
+}
@@ -0,0 +1,175 @@
+// Package fit decodes the binary Flexible and Interoperable Data Transfer files that Garmin, Coros, Wahoo and
+// most other devices record activities in. Only the messages needed to import an activity are read: the
+// sessions, the laps and the records. Every other message, and developer data, is skipped.
+package fit
+
+import (
+	"time"
+)
+
+// Global message numbers of the FIT profile.
+const (
+	messageFileId  = 0
+	messageSession = 18
+	messageLap     = 19
+	messageRecord  = 20
+)
+
+// fieldTimestamp is the field number of the timestamp in every message that has one.
+const fieldTimestamp = 253
+
+// epoch is the start of FIT time. Timestamps count the seconds since then.
+var epoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// semicircle converts the positions of FIT files, stored as 32-bit semicircles, to degrees.
+const semicircle = 180.0 / (1 << 31)
+
+// File is the content of a FIT activity file.
+type File struct {
+	Sessions []Session
+	Laps     []Lap
+	Records  []Record
+}
+
+// Summary is the totals of a session or a lap. Values that the device did not record are 0.
+type Summary struct {
+	StartTime time.Time
+	// ElapsedTime is the time in seconds from start to end, while TimerTime leaves out the pauses.
+	ElapsedTime      float64
+	TimerTime        float64
+	Distance         float64
+	TotalAscent      int
+	AverageHeartRate int
+	MaxHeartRate     int
+}
+
+// Session is an activity, or one sport of a multisport activity.
+type Session struct {
+	Summary
+}
+
+// Lap is a lap of a session, either pressed by the athlete or recorded automatically.
+type Lap struct {
+	Summary
+}
+
+// Record is a sample of the activity, usually one per second.
+type Record struct {
+	Timestamp   time.Time
+	HasPosition bool
+	Latitude    float64
+	Longitude   float64
+	HasAltitude bool
+	Altitude    float64
+	// Distance is the distance covered since the start in meters, or 0 if it was not recorded.
+	Distance  float64
+	HeartRate int
+	Cadence   int
+	Power     int
+}
+
+// Field numbers of the session, lap and record messages.
+const (
+	sessionStartTime        = 2
+	sessionElapsedTime      = 7
+	sessionTimerTime        = 8
+	sessionDistance         = 9
+	sessionAverageHeartRate = 16
+	sessionMaxHeartRate     = 17
+	sessionTotalAscent      = 22
+
+	lapStartTime        = 2
+	lapElapsedTime      = 7
+	lapTimerTime        = 8
+	lapDistance         = 9
+	lapAverageHeartRate = 15
+	lapMaxHeartRate     = 16
+	lapTotalAscent      = 21
+
+	recordLatitude         = 0
+	recordLongitude        = 1
+	recordAltitude         = 2
+	recordHeartRate        = 3
+	recordCadence          = 4
+	recordDistance         = 5
+	recordPower            = 7
+	recordEnhancedAltitude = 78
+)
+
+// message is a decoded data message, with the valid values of its integer fields.
+type message struct {
+	global int
+	fields map[byte]int64
+}
+
+func (m message) value(field byte) (int64, bool) {
+	value, ok := m.fields[field]
+	return value, ok
+}
+
+func (m message) int(field byte) int {
+	return int(m.fields[field])
+}
+
+// scaled applies the scale of a field, like the 1000 of durations stored in milliseconds.
+func (m message) scaled(field byte, scale float64) float64 {
+	return float64(m.fields[field]) / scale
+}
+
+func (m message) time(field byte) time.Time {
+	value, ok := m.value(field)
+	if !ok {
+		return time.Time{}
+	}
+	return timeOf(value)
+}
+
+func timeOf(value int64) time.Time {
+	return epoch.Add(time.Duration(value) * time.Second)
+}
+
+func summaryOf(m message, startTime, elapsedTime, timerTime, distance, ascent, averageHeartRate, maxHeartRate byte) Summary {
+	return Summary{
+		StartTime:        m.time(startTime),
+		ElapsedTime:      m.scaled(elapsedTime, 1000),
+		TimerTime:        m.scaled(timerTime, 1000),
+		Distance:         m.scaled(distance, 100),
+		TotalAscent:      m.int(ascent),
+		AverageHeartRate: m.int(averageHeartRate),
+		MaxHeartRate:     m.int(maxHeartRate),
+	}
+}
+
+func (f *File) add(m message) {
+	switch m.global {
+	case messageSession:
+		f.Sessions = append(f.Sessions, Session{summaryOf(m, sessionStartTime, sessionElapsedTime,
+			sessionTimerTime, sessionDistance, sessionTotalAscent, sessionAverageHeartRate, sessionMaxHeartRate)})
+	case messageLap:
+		f.Laps = append(f.Laps, Lap{summaryOf(m, lapStartTime, lapElapsedTime,
+			lapTimerTime, lapDistance, lapTotalAscent, lapAverageHeartRate, lapMaxHeartRate)})
+	case messageRecord:
+		record := Record{
+			Timestamp: m.time(fieldTimestamp),
+			Distance:  m.scaled(recordDistance, 100),
+			HeartRate: m.int(recordHeartRate),
+			Cadence:   m.int(recordCadence),
+			Power:     m.int(recordPower),
+		}
+		latitude, hasLatitude := m.value(recordLatitude)
+		longitude, hasLongitude := m.value(recordLongitude)
+		if hasLatitude && hasLongitude {
+			record.HasPosition = true
+			record.Latitude, record.Longitude = float64(latitude)*semicircle, float64(longitude)*semicircle
+		}
+		altitude, hasAltitude := m.value(recordEnhancedAltitude)
+		if !hasAltitude {
+			altitude, hasAltitude = m.value(recordAltitude)
+		}
+		if hasAltitude {
+			// Altitudes are stored in fifths of a meter, starting 500 meters below sea level.
+			record.HasAltitude, record.Altitude = true, float64(altitude)/5-500
+		}
+		f.Records = append(f.Records, record)
+	}
+}
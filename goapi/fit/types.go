@@ -0,0 +1,50 @@
+package fit
+
+// Base types of the fields. The high bit is set for the types that are more than one byte long.
+const (
+	baseEnum    = 0x00
+	baseSint8   = 0x01
+	baseUint8   = 0x02
+	baseSint16  = 0x83
+	baseUint16  = 0x84
+	baseSint32  = 0x85
+	baseUint32  = 0x86
+	baseString  = 0x07
+	baseUint8z  = 0x0a
+	baseUint16z = 0x8b
+	baseUint32z = 0x8c
+	baseByte    = 0x0d
+)
+
+// invalidValues are the values that mark a field as not set. The z types use 0 instead of all bits set.
+var invalidValues = map[byte]uint64{
+	baseEnum:    0xff,
+	baseSint8:   0x7f,
+	baseUint8:   0xff,
+	baseSint16:  0x7fff,
+	baseUint16:  0xffff,
+	baseSint32:  0x7fffffff,
+	baseUint32:  0xffffffff,
+	baseUint8z:  0,
+	baseUint16z: 0,
+	baseUint32z: 0,
+	baseByte:    0xff,
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// checksum computes the CRC-16 that FIT files end with.
+func checksum(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		for _, nibble := range [2]byte{b & 0x0f, b >> 4} {
+			tmp := crcTable[crc&0x0f]
+			crc = (crc >> 4) & 0x0fff
+			crc = crc ^ tmp ^ crcTable[nibble]
+		}
+	}
+	return crc
+}
@@ -30,4 +30,39 @@ func GetIntArgument(p graphql.ResolveParams, key string) (int, error) {
 	}
 
 	return val, nil
-}
\ No newline at end of file
+}
+
+func GetStringListArgument(p graphql.ResolveParams, key string) ([]string, error) {
+	values, ok := p.Args[key].([]interface{})
+	if !ok {
+		return nil, errors.New(key + " not found")
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, value.(string))
+	}
+	return result, nil
+}
+
+func GetIntListArgument(p graphql.ResolveParams, key string) ([]int, error) {
+	values, ok := p.Args[key].([]interface{})
+	if !ok {
+		return nil, errors.New(key + " not found")
+	}
+
+	result := make([]int, 0, len(values))
+	for _, value := range values {
+		result = append(result, value.(int))
+	}
+	return result, nil
+}
+
+func GetFloatArgument(p graphql.ResolveParams, key string) (float64, error) {
+	val, ok := p.Args[key].(float64)
+	if !ok {
+		return 0, errors.New(key + " not found")
+	}
+
+	return val, nil
+}
@@ -0,0 +1,485 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/gql-common"
+	"goapi/logger"
+	"goapi/models"
+	"time"
+)
+
+var errPrivateActivities = errors.New("the activities of a profile are only visible to its owner")
+
+func activityFields(dbClient database.Client, workoutV2Type, dayType *graphql.Object) graphql.Fields {
+	return graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"startTime": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "The time the activity started, formatted as RFC 3339",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Activity).StartTime.Format(time.RFC3339), nil
+			},
+		},
+		"duration": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The duration in seconds",
+		},
+		"distance": optionalActivityField("The distance in meters", func(activity models.Activity) int {
+			return activity.Distance
+		}),
+		"averageHeartRate": optionalActivityField("The average heart rate in beats per minute", func(activity models.Activity) int {
+			return activity.AverageHeartRate
+		}),
+		"rpe": optionalActivityField("The rate of perceived exertion on the 1-10 scale", func(activity models.Activity) int {
+			return activity.Rpe
+		}),
+		"notes": &graphql.Field{
+			Type: graphql.String,
+		},
+		"workout": &graphql.Field{
+			Type:        workoutV2Type,
+			Description: "The planned workout the activity completed",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				activity := p.Source.(models.Activity)
+				if activity.WorkoutId == "" {
+					return nil, nil
+				}
+				return dbClient.GetWorkout(p.Context, activity.WorkoutId)
+			},
+		},
+		"day": &graphql.Field{
+			Type:        dayType,
+			Description: "The plan day the activity completed",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				activity := p.Source.(models.Activity)
+				if activity.DayId == "" {
+					return nil, nil
+				}
+				return dbClient.GetDay(p.Context, activity.DayId)
+			},
+		},
+	}
+}
+
+func activityType(dbClient database.Client, workoutV2Type, dayType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:   "Activity",
+			Fields: activityFields(dbClient, workoutV2Type, dayType),
+		},
+	)
+}
+
+func activityConnectionType(activityType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "ActivityConnection",
+			Description: "The activities of a period, latest first, with totals",
+			Fields: graphql.Fields{
+				"nodes": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(activityType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.([]models.Activity), nil
+					},
+				},
+				"totalCount": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return len(p.Source.([]models.Activity)), nil
+					},
+				},
+				"totalDuration": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The sum of the durations in seconds",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						total := 0
+						for _, activity := range p.Source.([]models.Activity) {
+							total += activity.Duration
+						}
+						return total, nil
+					},
+				},
+				"totalDistance": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The sum of the distances in meters",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						total := 0
+						for _, activity := range p.Source.([]models.Activity) {
+							total += activity.Distance
+						}
+						return total, nil
+					},
+				},
+			}})
+}
+
+func optionalActivityField(description string, value func(models.Activity) int) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.Int,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if value := value(p.Source.(models.Activity)); value > 0 {
+				return value, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// profileActivitiesField lists the activities of a profile between two dates. Only the owner of the profile
+// can see its activities.
+func profileActivitiesField(dbClient database.Client, activityConnectionType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(activityConnectionType),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			viewer, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+			if viewer.Id != profile.Id {
+				return nil, errPrivateActivities
+			}
+
+			from, to, err := dateRangeArguments(p)
+			if err != nil {
+				return nil, err
+			}
+			return dbClient.GetActivities(p.Context, profile.Id, from, to)
+		},
+		Args: dateRangeArgumentConfig(),
+	}
+}
+
+// dateRangeArguments reads the from and to dates of a period. The period includes the to date, so the returned
+// end is the start of the day after. Dates that are left out leave that end of the period open.
+func dateRangeArguments(p graphql.ResolveParams) (time.Time, time.Time, error) {
+	var from, to time.Time
+	if value, err := gqlcommon.GetStringArgument(p, "from"); err == nil {
+		from, err = time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if value, err := gqlcommon.GetStringArgument(p, "to"); err == nil {
+		to, err = time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+func dateRangeArgumentConfig() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"from": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The first date of the period, formatted as YYYY-MM-DD",
+		},
+		"to": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The last date of the period, formatted as YYYY-MM-DD",
+		},
+	}
+}
+
+// ownedActivity fetches an activity and verifies that it belongs to the logged in user.
+func ownedActivity(p graphql.ResolveParams, dbClient database.Client, activityId string) (models.Activity, error) {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return models.Activity{}, err
+	}
+
+	activity, err := dbClient.GetActivity(p.Context, activityId)
+	if err != nil {
+		return models.Activity{}, err
+	}
+	if activity.ProfileId != profile.Id {
+		logger.FromContext(p.Context).Warn("The user tried to change an activity logged by someone else")
+		return models.Activity{}, errNotOwner
+	}
+
+	return activity, nil
+}
+
+// activityFromArguments applies the given arguments to an activity, keeping the values that are left out.
+// An empty workoutId or dayId removes the link to the planned workout or day.
+func activityFromArguments(p graphql.ResolveParams, dbClient database.Client, activity models.Activity) (models.Activity, error) {
+	if value, err := gqlcommon.GetStringArgument(p, "startTime"); err == nil {
+		activity.StartTime, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return models.Activity{}, errors.New("startTime must be formatted as RFC 3339, like 2020-05-01T07:30:00+02:00")
+		}
+	}
+	for key, value := range map[string]*int{
+		"duration":         &activity.Duration,
+		"distance":         &activity.Distance,
+		"averageHeartRate": &activity.AverageHeartRate,
+		"rpe":              &activity.Rpe,
+	} {
+		if argument, exist := p.Args[key].(int); exist {
+			if argument < 0 {
+				return models.Activity{}, errors.New(key + " can not be negative")
+			}
+			*value = argument
+		}
+	}
+	if notes, err := gqlcommon.GetStringArgument(p, "notes"); err == nil {
+		activity.Notes = notes
+	}
+	if workoutId, err := gqlcommon.GetStringArgument(p, "workoutId"); err == nil {
+		if workoutId != "" {
+			if err := ensureLinkableWorkout(p, dbClient, workoutId); err != nil {
+				return models.Activity{}, err
+			}
+		}
+		activity.WorkoutId = workoutId
+	}
+	if dayId, err := gqlcommon.GetStringArgument(p, "dayId"); err == nil {
+		if dayId != "" {
+			if err := ensureLinkableDay(p, dbClient, dayId); err != nil {
+				return models.Activity{}, err
+			}
+		}
+		activity.DayId = dayId
+	}
+
+	if activity.StartTime.IsZero() {
+		return models.Activity{}, errors.New("the activity must have a start time")
+	}
+	if activity.Duration <= 0 {
+		return models.Activity{}, errors.New("the activity must have a duration")
+	}
+	if activity.Rpe > 10 {
+		return models.Activity{}, errors.New("rpe must be on the 1-10 scale")
+	}
+	return activity, nil
+}
+
+// ensureLinkableWorkout verifies that the logged in user created the workout, or has it on a day of a plan they
+// created or scheduled.
+func ensureLinkableWorkout(p graphql.ResolveParams, dbClient database.Client, workoutId string) error {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return err
+	}
+	workout, err := dbClient.GetWorkout(p.Context, workoutId)
+	if err != nil {
+		return err
+	}
+	if workout.CreatedBy == profile.Id {
+		return nil
+	}
+
+	planIds, err := linkablePlanIds(p, dbClient, profile.Id)
+	if err != nil {
+		return err
+	}
+	for _, planId := range planIds {
+		weeks, err := dbClient.GetWeeksForPlan(p.Context, planId)
+		if err != nil {
+			return err
+		}
+		for _, week := range weeks {
+			days, err := dbClient.GetDaysForWeek(p.Context, week.Id)
+			if err != nil {
+				return err
+			}
+			for _, day := range days {
+				workouts, err := dbClient.GetWorkoutsForDay(p.Context, day.Id)
+				if err != nil {
+					return err
+				}
+				for _, planned := range workouts {
+					if planned.Id == workoutId {
+						return nil
+					}
+				}
+			}
+		}
+	}
+
+	logger.FromContext(p.Context).Warn("The user tried to link an activity to a workout of someone else")
+	return errNotOwner
+}
+
+// ensureLinkableDay verifies that the day belongs to a plan the logged in user created or scheduled.
+func ensureLinkableDay(p graphql.ResolveParams, dbClient database.Client, dayId string) error {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return err
+	}
+	day, err := dbClient.GetDay(p.Context, dayId)
+	if err != nil {
+		return err
+	}
+	week, err := dbClient.GetWeek(p.Context, day.WeekId)
+	if err != nil {
+		return err
+	}
+
+	planIds, err := linkablePlanIds(p, dbClient, profile.Id)
+	if err != nil {
+		return err
+	}
+	for _, planId := range planIds {
+		if planId == week.PlanId {
+			return nil
+		}
+	}
+
+	logger.FromContext(p.Context).Warn("The user tried to link an activity to a day of someone else")
+	return errNotOwner
+}
+
+// linkablePlanIds lists the plans a profile created or scheduled, whose days and workouts its activities can
+// be linked to.
+func linkablePlanIds(p graphql.ResolveParams, dbClient database.Client, profileId string) ([]string, error) {
+	seen := map[string]bool{}
+	planIds := []string{}
+	plans, err := dbClient.GetPlans(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	for _, plan := range plans {
+		if plan.CreatedBy == profileId {
+			seen[plan.Id] = true
+			planIds = append(planIds, plan.Id)
+		}
+	}
+	schedules, err := dbClient.GetSchedulesForProfile(p.Context, profileId)
+	if err != nil {
+		return nil, err
+	}
+	for _, planSchedule := range schedules {
+		if !seen[planSchedule.PlanId] {
+			seen[planSchedule.PlanId] = true
+			planIds = append(planIds, planSchedule.PlanId)
+		}
+	}
+	return planIds, nil
+}
+
+func activityArgumentConfig(required bool) graphql.FieldConfigArgument {
+	startTime, duration := graphql.Input(graphql.String), graphql.Input(graphql.Int)
+	if required {
+		startTime, duration = graphql.NewNonNull(graphql.String), graphql.NewNonNull(graphql.Int)
+	}
+
+	return graphql.FieldConfigArgument{
+		"startTime": &graphql.ArgumentConfig{
+			Type:        startTime,
+			Description: "The time the activity started, formatted as RFC 3339",
+		},
+		"duration": &graphql.ArgumentConfig{
+			Type:        duration,
+			Description: "The duration in seconds",
+		},
+		"distance": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The distance in meters",
+		},
+		"averageHeartRate": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"rpe": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The rate of perceived exertion on the 1-10 scale",
+		},
+		"notes": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"workoutId": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The planned workout the activity completed. Either created by the user, or on a plan they created or scheduled.",
+		},
+		"dayId": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The plan day the activity completed, on a plan the user created or scheduled",
+		},
+	}
+}
+
+func logActivityMutation(dbClient database.Client, activityType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type: activityType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			activity, err := activityFromArguments(p, dbClient, models.Activity{ProfileId: profile.Id})
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.LogActivity(p.Context, activity)
+		},
+		Args: activityArgumentConfig(true),
+	}
+}
+
+func updateActivityMutation(dbClient database.Client, activityType *graphql.Object) *graphql.Field {
+	args := activityArgumentConfig(false)
+	args["id"] = &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.String),
+	}
+
+	return &graphql.Field{
+		Type: activityType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			activity, err := ownedActivity(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			activity, err = activityFromArguments(p, dbClient, activity)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.UpdateActivity(p.Context, activity)
+		},
+		Args: args,
+	}
+}
+
+func deleteActivityMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedActivity(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = dbClient.DeleteActivity(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/models"
+	"goapi/tracks"
+	"math"
+	"time"
+)
+
+const defaultSplitMeters = 1000
+
+var lapType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "Lap",
+		Description: "A lap or split of a recorded activity",
+		Fields: graphql.Fields{
+			"order": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"startTime": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The time the lap started, formatted as RFC 3339",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Lap).StartTime.Format(time.RFC3339), nil
+				},
+			},
+			"duration": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The duration in seconds",
+			},
+			"distance": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Float),
+				Description: "The distance in meters",
+			},
+			"pace": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "The pace in seconds per kilometer",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					lap := p.Source.(models.Lap)
+					if lap.Distance <= 0 {
+						return nil, nil
+					}
+					return int(math.Round(lap.Duration / lap.Distance * 1000)), nil
+				},
+			},
+			"averageHeartRate": optionalLapField(func(lap models.Lap) int {
+				return lap.AverageHeartRate
+			}),
+			"maxHeartRate": optionalLapField(func(lap models.Lap) int {
+				return lap.MaxHeartRate
+			}),
+		}})
+
+func optionalLapField(value func(models.Lap) int) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.Int,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if value := value(p.Source.(models.Lap)); value > 0 {
+				return value, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// addRecordedActivityFields adds the values that are only known for activities uploaded from a file.
+func addRecordedActivityFields(dbClient database.Client, activityType *graphql.Object) {
+	activityType.AddFieldConfig("movingTime", optionalActivityField("The time in seconds spent moving", func(activity models.Activity) int {
+		return activity.MovingTime
+	}))
+	activityType.AddFieldConfig("elevationGain", optionalActivityField("The total climb in meters", func(activity models.Activity) int {
+		return activity.ElevationGain
+	}))
+	activityType.AddFieldConfig("maxHeartRate", optionalActivityField("The max heart rate in beats per minute", func(activity models.Activity) int {
+		return activity.MaxHeartRate
+	}))
+	activityType.AddFieldConfig("source", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.String),
+		Description: "Where the activity comes from, like manual or gpx",
+	})
+	activityType.AddFieldConfig("laps", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(lapType))),
+		Description: "The laps recorded by the device",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return dbClient.GetLaps(p.Context, p.Source.(models.Activity).Id)
+		},
+	})
+	activityType.AddFieldConfig("splits", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(lapType))),
+		Description: "The recorded track divided into laps of equal distance",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			distance, _ := p.Args["distance"].(int)
+			if distance <= 0 {
+				return nil, errors.New("distance must be positive")
+			}
+			samples, err := dbClient.GetSamples(p.Context, p.Source.(models.Activity).Id)
+			if err != nil {
+				return nil, err
+			}
+			return tracks.Splits(samples, float64(distance)), nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"distance": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: defaultSplitMeters,
+				Description:  "The distance of a split in meters",
+			},
+		},
+	})
+}
@@ -0,0 +1,445 @@
+package gqlschema
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"goapi/adherence"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/load"
+	"goapi/models"
+	"goapi/schedule"
+	"time"
+)
+
+var complianceStatus = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "ComplianceStatus",
+	Description: "How a day went compared with the plan",
+	Values: graphql.EnumValueConfigMap{
+		"DONE": &graphql.EnumValueConfig{
+			Value:       adherence.Done,
+			Description: "The activities covered at least 80 % of the planned duration, distance and load",
+		},
+		"PARTIAL": &graphql.EnumValueConfig{
+			Value:       adherence.Partial,
+			Description: "The activities covered less than 80 % of the plan",
+		},
+		"MISSED": &graphql.EnumValueConfig{
+			Value:       adherence.Missed,
+			Description: "Nothing was logged for a planned day",
+		},
+		"EXTRA": &graphql.EnumValueConfig{
+			Value:       adherence.Extra,
+			Description: "Activities that were not planned, like runs on rest days",
+		},
+	},
+})
+
+// adherenceReport is the outcome of the days of a period, with weekly summaries.
+type adherenceReport struct {
+	Days  []adherence.Day
+	Weeks []weekAdherence
+}
+
+// weekAdherence is the summary of a week, with the order of the week in its plan when there is one.
+type weekAdherence struct {
+	Week  *int
+	Start time.Time
+	adherence.Summary
+}
+
+func summaryFields(summary func(p graphql.ResolveParams) adherence.Summary) graphql.Fields {
+	count := func(description string, value func(adherence.Summary) int) *graphql.Field {
+		return &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: description,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return value(summary(p)), nil
+			},
+		}
+	}
+
+	return graphql.Fields{
+		"percentage": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "The average compliance of the planned days that are due, from 0 to 100. Empty when none are due.",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				percentage, ok := summary(p).Percentage()
+				if !ok {
+					return nil, nil
+				}
+				return percentage, nil
+			},
+		},
+		"planned": count("The number of planned days that are due", func(s adherence.Summary) int { return s.Planned }),
+		"done":    count("The number of planned days that were done", func(s adherence.Summary) int { return s.Done }),
+		"partial": count("The number of planned days that were partly done", func(s adherence.Summary) int { return s.Partial }),
+		"missed":  count("The number of planned days that were missed", func(s adherence.Summary) int { return s.Missed }),
+		"extra":   count("The number of days with activities that were not planned", func(s adherence.Summary) int { return s.Extra }),
+	}
+}
+
+var weekAdherenceType = func() *graphql.Object {
+	fields := summaryFields(func(p graphql.ResolveParams) adherence.Summary {
+		return p.Source.(weekAdherence).Summary
+	})
+	fields["startDate"] = &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.String),
+		Description: "The first date of the week, formatted as YYYY-MM-DD",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(weekAdherence).Start.Format(dateLayout), nil
+		},
+	}
+	fields["week"] = &graphql.Field{
+		Type:        graphql.Int,
+		Description: "The order of the week in the plan. Empty for weeks of a profile, which can span several plans.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if week := p.Source.(weekAdherence).Week; week != nil {
+				return *week, nil
+			}
+			return nil, nil
+		},
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        "WeekAdherence",
+		Description: "How well a week followed the plan",
+		Fields:      fields,
+	})
+}()
+
+func dayComplianceType(dbClient database.Client, dayType, activityType *graphql.Object) *graphql.Object {
+	planned := func(description string, value func(adherence.Planned) float64, round bool) *graphql.Field {
+		fieldType := graphql.Output(graphql.Float)
+		if round {
+			fieldType = graphql.Int
+		}
+		return &graphql.Field{
+			Type:        fieldType,
+			Description: description,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				day := p.Source.(adherence.Day)
+				if day.Planned == nil || value(*day.Planned) <= 0 {
+					return nil, nil
+				}
+				if round {
+					return int(value(*day.Planned) + 0.5), nil
+				}
+				return value(*day.Planned), nil
+			},
+		}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        "DayCompliance",
+		Description: "A planned day and the activities logged for it, or activities on a day without a plan",
+		Fields: graphql.Fields{
+			"date": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The date, formatted as YYYY-MM-DD",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(adherence.Day).Date.Format(dateLayout), nil
+				},
+			},
+			"status": &graphql.Field{
+				Type: graphql.NewNonNull(complianceStatus),
+			},
+			"compliance": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "How much of the plan the activities covered, from 0 to 1. Empty for extra days.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					day := p.Source.(adherence.Day)
+					if day.Status == adherence.Extra {
+						return nil, nil
+					}
+					return day.Compliance, nil
+				},
+			},
+			"day": &graphql.Field{
+				Type:        dayType,
+				Description: "The planned day. Empty for activities on dates without a plan.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					day := p.Source.(adherence.Day)
+					if day.Planned == nil {
+						return nil, nil
+					}
+					return dbClient.GetDay(p.Context, day.Planned.DayId)
+				},
+			},
+			"activities": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(activityType))),
+			},
+			"plannedSeconds": planned("The estimated duration of the planned workouts in seconds", func(planned adherence.Planned) float64 {
+				return planned.Seconds
+			}, true),
+			"plannedMeters": planned("The estimated distance of the planned workouts in meters", func(planned adherence.Planned) float64 {
+				return planned.Meters
+			}, true),
+			"plannedLoad": planned("The estimated load of the planned workouts", func(planned adherence.Planned) float64 {
+				return planned.Load
+			}, false),
+			"seconds": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The total duration of the activities in seconds",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return int(p.Source.(adherence.Day).Seconds()), nil
+				},
+			},
+			"meters": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The total distance of the activities in meters",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return int(p.Source.(adherence.Day).Meters()), nil
+				},
+			},
+			"load": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "The session RPE load of the activities, minutes times RPE / 10. Empty when an activity has no RPE.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					value, ok := p.Source.(adherence.Day).Load()
+					if !ok {
+						return nil, nil
+					}
+					return value, nil
+				},
+			},
+		},
+	})
+}
+
+func adherenceType(dayComplianceType *graphql.Object) *graphql.Object {
+	fields := summaryFields(func(p graphql.ResolveParams) adherence.Summary {
+		return adherence.Summarize(p.Source.(adherenceReport).Days)
+	})
+	fields["weeks"] = &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(weekAdherenceType))),
+	}
+	fields["days"] = &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(dayComplianceType))),
+		Description: "The planned days that are due and the days with extra activities, in date order",
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Adherence",
+		Description: "How well the logged activities followed the scheduled plans",
+		Fields:      fields,
+	})
+}
+
+// plannedDays lists the planned days of a schedule with the estimated totals of their workouts.
+func plannedDays(ctx context.Context, dbClient database.Client, estimator estimates.Estimator, planSchedule models.PlanSchedule) ([]adherence.Planned, error) {
+	days, err := schedule.Days(ctx, dbClient, planSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	estimated := map[string]adherence.Planned{}
+	planned := make([]adherence.Planned, 0, len(days))
+	for _, day := range days {
+		workouts, err := dbClient.GetWorkoutsForDay(ctx, day.Day.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		plannedDay := adherence.Planned{Date: day.Date, DayId: day.Day.Id}
+		estimatedSeconds, estimatedLoad := true, true
+		for _, workout := range workouts {
+			totals, exist := estimated[workout.Id]
+			if !exist {
+				parts, err := dbClient.GetWorkoutPartsForWorkout(ctx, workout.Id)
+				if err != nil {
+					return nil, err
+				}
+				estimate, _ := estimator.Parts(parts)
+				value, _ := load.Parts(estimator, parts)
+				totals = adherence.Planned{Seconds: estimate.Seconds, Meters: estimate.Meters, Load: value}
+				estimated[workout.Id] = totals
+			}
+
+			plannedDay.WorkoutIds = append(plannedDay.WorkoutIds, workout.Id)
+			plannedDay.Seconds += totals.Seconds
+			plannedDay.Meters += totals.Meters
+			plannedDay.Load += totals.Load
+			estimatedSeconds = estimatedSeconds && totals.Seconds > 0
+			estimatedLoad = estimatedLoad && totals.Load > 0
+		}
+		// A day with a workout that can not be estimated has unknown totals, rather than the totals of the rest.
+		if !estimatedSeconds {
+			plannedDay.Seconds, plannedDay.Meters = 0, 0
+		}
+		if !estimatedLoad {
+			plannedDay.Load = 0
+		}
+		planned = append(planned, plannedDay)
+	}
+	return planned, nil
+}
+
+// scheduleLocation is the time zone of a schedule, falling back to UTC for unknown zones.
+func scheduleLocation(planSchedule models.PlanSchedule) *time.Location {
+	location, err := time.LoadLocation(planSchedule.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// localDate is the date of a time in the location, as midnight UTC like the dates of schedules.
+func localDate(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// activitiesAround lists the activities of a profile that may fall on the dates in [from, to) in some time zone.
+func activitiesAround(ctx context.Context, dbClient database.Client, profileId string, from, to time.Time) ([]models.Activity, error) {
+	if !from.IsZero() {
+		from = from.AddDate(0, 0, -1)
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+	return dbClient.GetActivities(ctx, profileId, from, to)
+}
+
+// planAdherenceField reports how well the logged in user followed the plan, from their latest schedule of it.
+// It is empty when the user has not scheduled the plan.
+func planAdherenceField(dbClient database.Client, adherenceType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        adherenceType,
+		Description: "How well the logged in user followed their latest schedule of the plan, week by week",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			plan := p.Source.(models.Plan)
+			profile, ok := viewingProfile(p)
+			if !ok {
+				return nil, nil
+			}
+
+			schedules, err := dbClient.GetSchedulesForProfile(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			var planSchedule *models.PlanSchedule
+			for i := range schedules {
+				if schedules[i].PlanId == plan.Id {
+					planSchedule = &schedules[i]
+				}
+			}
+			if planSchedule == nil {
+				return nil, nil
+			}
+
+			estimator, err := viewerEstimator(p, dbClient, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			planned, err := plannedDays(p.Context, dbClient, estimator, *planSchedule)
+			if err != nil {
+				return nil, err
+			}
+			weeks, err := dbClient.GetWeeksForPlan(p.Context, plan.Id)
+			if err != nil {
+				return nil, err
+			}
+			start, end := schedule.Start(*planSchedule, len(weeks)), schedule.End(*planSchedule, len(weeks))
+			activities, err := activitiesAround(p.Context, dbClient, profile.Id, start, end.AddDate(0, 0, 1))
+			if err != nil {
+				return nil, err
+			}
+
+			location := scheduleLocation(*planSchedule)
+			within := []models.Activity{}
+			for _, activity := range activities {
+				if activityDate := localDate(activity.StartTime, location); !activityDate.Before(start) && !activityDate.After(end) {
+					within = append(within, activity)
+				}
+			}
+			days := adherence.Match(planned, within, location, time.Now().In(location))
+
+			orders := []int{}
+			for _, week := range weeks {
+				if !schedule.IsSkipped(*planSchedule, week.Order) {
+					orders = append(orders, week.Order)
+				}
+			}
+			report := adherenceReport{Days: days, Weeks: []weekAdherence{}}
+			for i, week := range adherence.Weeks(days, start) {
+				order := orders[i]
+				report.Weeks = append(report.Weeks, weekAdherence{Week: &order, Start: week.Start, Summary: week.Summary})
+			}
+			return report, nil
+		},
+	}
+}
+
+// profileAdherenceField reports how well a profile followed all its scheduled plans between two dates, in
+// weeks from Monday. Only the owner of the profile can see it.
+func profileAdherenceField(dbClient database.Client, adherenceType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(adherenceType),
+		Description: "How well the activities followed the scheduled plans, in weeks from Monday. Only visible to the owner.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			if err := ownProfile(p, profile); err != nil {
+				return nil, err
+			}
+			from, to, err := dateRangeArguments(p)
+			if err != nil {
+				return nil, err
+			}
+
+			schedules, err := dbClient.GetSchedulesForProfile(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			estimator, err := viewerEstimator(p, dbClient, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			inPeriod := func(date time.Time) bool {
+				return (from.IsZero() || !date.Before(from)) && (to.IsZero() || date.Before(to))
+			}
+
+			// The days are dated in the time zone of the latest schedule, where the athlete is most likely to be.
+			location := time.UTC
+			planned := []adherence.Planned{}
+			for _, planSchedule := range schedules {
+				location = scheduleLocation(planSchedule)
+				days, err := plannedDays(p.Context, dbClient, estimator, planSchedule)
+				if err != nil {
+					return nil, err
+				}
+				for _, day := range days {
+					if inPeriod(day.Date) {
+						planned = append(planned, day)
+					}
+				}
+			}
+
+			activities, err := activitiesAround(p.Context, dbClient, profile.Id, from, to)
+			if err != nil {
+				return nil, err
+			}
+			within := []models.Activity{}
+			for _, activity := range activities {
+				if inPeriod(localDate(activity.StartTime, location)) {
+					within = append(within, activity)
+				}
+			}
+			days := adherence.Match(planned, within, location, time.Now().In(location))
+
+			report := adherenceReport{Days: days, Weeks: []weekAdherence{}}
+			start := from
+			if start.IsZero() && len(days) > 0 {
+				start = days[0].Date
+			}
+			if !start.IsZero() {
+				start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+				for _, week := range adherence.Weeks(days, start) {
+					report.Weeks = append(report.Weeks, weekAdherence{Start: week.Start, Summary: week.Summary})
+				}
+			}
+			return report, nil
+		},
+		Args: dateRangeArgumentConfig(),
+	}
+}
@@ -0,0 +1,29 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/appcontext"
+	"goapi/logger"
+	"goapi/models"
+)
+
+var errNotOwner = errors.New("the user is not allowed to change this entity")
+
+// authenticatedProfile returns the profile of the logged in user, or an error if nobody is logged in.
+func authenticatedProfile(p graphql.ResolveParams) (models.Profile, error) {
+	log := logger.FromContext(p.Context)
+
+	authenticated, err := appcontext.UserAuthenticated(p.Context)
+	if err != nil || !authenticated {
+		log.Error("The user must be logged in to use this query")
+		return models.Profile{}, errors.New("the user must be logged in to use this query")
+	}
+	profile, err := appcontext.Profile(p.Context)
+	if err != nil {
+		log.Error("Profile expected to be on Context, but was not found.")
+		return models.Profile{}, errors.New("unexpected error")
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,52 @@
+package gqlschema
+
+import (
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/models"
+)
+
+// calendarPath is the path of the calendar feed with the token, relative to the API.
+func calendarPath(token string) string {
+	return "/calendar/" + token + ".ics"
+}
+
+// calendarPathField shows the owner of a profile the path of their calendar feed, or null before they have one.
+func calendarPathField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.String,
+		Description: "The path of the iCalendar feed of the scheduled plans, relative to the API. Only visible to the owner.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			viewer, ok := viewingProfile(p)
+			if !ok || viewer.Id != profile.Id {
+				return nil, nil
+			}
+
+			token, err := dbClient.GetCalendarToken(p.Context, profile.Id)
+			if err != nil || token == "" {
+				return nil, err
+			}
+			return calendarPath(token), nil
+		},
+	}
+}
+
+func resetCalendarTokenMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.String),
+		Description: "Creates a new secret path for the calendar feed of the logged in user. The old path stops working.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			token, err := dbClient.ResetCalendarToken(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			return calendarPath(token), nil
+		},
+	}
+}
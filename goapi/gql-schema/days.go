@@ -2,11 +2,13 @@ package gqlschema
 
 import (
 	"github.com/graphql-go/graphql"
-	"goapi/resolvables/days"
-	"goapi/resolvables/workouts"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/models"
+	"math"
 )
 
-func dayFields(resolvableWorkouts workouts.Resolvable, workoutType *graphql.Object) graphql.Fields {
+func dayFields(dbClient database.Client, workoutV2Type *graphql.Object) graphql.Fields {
 	return graphql.Fields{
 		"id": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.String),
@@ -15,35 +17,89 @@ func dayFields(resolvableWorkouts workouts.Resolvable, workoutType *graphql.Obje
 			Type: graphql.NewNonNull(graphql.Int),
 		},
 		"workouts": &graphql.Field{
-			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workoutType))),
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workoutV2Type))),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				ids :=  p.Source.(days.Day).Workouts
-				ws, err := resolvableWorkouts.GetByIds(p.Context, ids)
+				return dbClient.GetWorkoutsForDay(p.Context, p.Source.(models.Day).Id)
+			},
+		},
+		"distance": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The distance in meters, with parts measured in seconds estimated at the viewer's training paces",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				day := p.Source.(models.Day)
+				week, err := dbClient.GetWeek(p.Context, day.WeekId)
 				if err != nil {
 					return nil, err
 				}
-				wsMap := make(map[string]workouts.Workout)
-				for _, bt := range ws {
-					wsMap[bt.Id] = bt
-				}
-				var allWs workouts.Workouts
-				for _, id := range ids {
-					allWs = append(allWs, wsMap[id])
-				}
-				return allWs, nil
+				return daysDistance(p, dbClient, week.PlanId, []models.Day{day})
 			},
 		},
-		"distance": &graphql.Field{
-			Type: graphql.NewNonNull(graphql.Int),
-		},
 	}
 }
 
-func dayType(resolvableWorkouts workouts.Resolvable, workoutType *graphql.Object) *graphql.Object {
+func dayType(dbClient database.Client, workoutV2Type *graphql.Object) *graphql.Object {
 	return graphql.NewObject(
 		graphql.ObjectConfig{
 			Name:   "Day",
-			Fields: dayFields(resolvableWorkouts, workoutType),
+			Fields: dayFields(dbClient, workoutV2Type),
 		},
 	)
 }
+
+// daysDistance sums up the distance of the workouts of the days. Parts measured in seconds count with the
+// distance estimated at the viewer's training paces, and are left out when their pace is unknown.
+func daysDistance(p graphql.ResolveParams, dbClient database.Client, planId string, days []models.Day) (int, error) {
+	dayIds := []string{}
+	for _, day := range days {
+		dayIds = append(dayIds, day.Id)
+	}
+	workoutsByDay, err := dbClient.GetWorkoutsForDays(p.Context, dayIds)
+	if err != nil {
+		return 0, err
+	}
+	workoutIds := []string{}
+	for _, workouts := range workoutsByDay {
+		for _, workout := range workouts {
+			workoutIds = append(workoutIds, workout.Id)
+		}
+	}
+	if len(workoutIds) == 0 {
+		return 0, nil
+	}
+	partsByWorkout, err := dbClient.GetWorkoutPartsForWorkouts(p.Context, workoutIds)
+	if err != nil {
+		return 0, err
+	}
+
+	plan, err := dbClient.GetPlan(p.Context, planId)
+	if err != nil {
+		return 0, err
+	}
+	estimator, err := viewerEstimator(p, dbClient, plan.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	distance := 0.0
+	for _, parts := range partsByWorkout {
+		distance += partsDistance(estimator, parts)
+	}
+	return int(math.Round(distance)), nil
+}
+
+func partsDistance(estimator estimates.Estimator, parts []models.WorkoutPart) float64 {
+	distance := 0.0
+	for _, part := range parts {
+		switch {
+		case part.IsRepeat():
+			distance += float64(part.Repeat) * partsDistance(estimator, part.Parts)
+		case part.Metric == "meter":
+			distance += float64(part.Distance)
+		default:
+			if estimate, ok := estimator.Part(part); ok {
+				distance += estimate.Meters
+			}
+		}
+	}
+	return distance
+}
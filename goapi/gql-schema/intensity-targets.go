@@ -0,0 +1,219 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/appcontext"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/models"
+	"goapi/targets"
+	"math"
+)
+
+var (
+	rangeType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "Range",
+			Description: "A range of percentages, or of RPE values on the 1-10 scale",
+			Fields: graphql.Fields{
+				"min": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Float),
+				},
+				"max": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Float),
+				},
+			}})
+
+	intRangeType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "IntRange",
+			Description: "A range of whole numbers, like beats per minute or watts",
+			Fields: graphql.Fields{
+				"min": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(models.Range).Min)), nil
+					},
+				},
+				"max": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(models.Range).Max)), nil
+					},
+				},
+			}})
+
+	intensityTargetsType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: "IntensityTargets",
+			Fields: graphql.Fields{
+				"heartRateMax": targetRangeField("Percent of max heart rate", func(t models.IntensityTargets) models.Range {
+					return t.HeartRateMax
+				}),
+				"heartRateReserve": targetRangeField("Percent of heart rate reserve", func(t models.IntensityTargets) models.Range {
+					return t.HeartRateReserve
+				}),
+				"vdot": targetRangeField("Percent of VDOT", func(t models.IntensityTargets) models.Range {
+					return t.Vdot
+				}),
+				"rpe": targetRangeField("Rate of perceived exertion on the 1-10 scale", func(t models.IntensityTargets) models.Range {
+					return t.Rpe
+				}),
+				"power": targetRangeField("Percent of threshold power", func(t models.IntensityTargets) models.Range {
+					return t.Power
+				}),
+			}})
+
+	rangeInputType = graphql.NewInputObject(
+		graphql.InputObjectConfig{
+			Name: "RangeInput",
+			Fields: graphql.InputObjectConfigFieldMap{
+				"min": &graphql.InputObjectFieldConfig{
+					Type: graphql.NewNonNull(graphql.Float),
+				},
+				"max": &graphql.InputObjectFieldConfig{
+					Type: graphql.NewNonNull(graphql.Float),
+				},
+			},
+		})
+
+	intensityTargetsInputType = graphql.NewInputObject(
+		graphql.InputObjectConfig{
+			Name:        "IntensityTargetsInput",
+			Description: "The targets of an intensity. Targets that are left out are not set.",
+			Fields: graphql.InputObjectConfigFieldMap{
+				"heartRateMax": &graphql.InputObjectFieldConfig{
+					Type: rangeInputType,
+				},
+				"heartRateReserve": &graphql.InputObjectFieldConfig{
+					Type: rangeInputType,
+				},
+				"vdot": &graphql.InputObjectFieldConfig{
+					Type: rangeInputType,
+				},
+				"rpe": &graphql.InputObjectFieldConfig{
+					Type: rangeInputType,
+				},
+				"power": &graphql.InputObjectFieldConfig{
+					Type: rangeInputType,
+				},
+			},
+		})
+)
+
+func init() {
+	intensityType.AddFieldConfig("targets", &graphql.Field{
+		Type: graphql.NewNonNull(intensityTargetsType),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(models.Intensity).Targets, nil
+		},
+	})
+	intensityType.AddFieldConfig("heartRate", &graphql.Field{
+		Type:        intRangeType,
+		Description: "The heart rate range of the logged in user, in beats per minute",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, ok := viewingProfile(p)
+			if !ok {
+				return nil, nil
+			}
+			heartRate, ok := targets.HeartRate(p.Source.(models.Intensity).Targets, profile)
+			if !ok {
+				return nil, nil
+			}
+			return heartRate, nil
+		},
+	})
+	intensityType.AddFieldConfig("power", &graphql.Field{
+		Type:        intRangeType,
+		Description: "The power range of the logged in user, in watts",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, ok := viewingProfile(p)
+			if !ok {
+				return nil, nil
+			}
+			power, ok := targets.Power(p.Source.(models.Intensity).Targets, profile)
+			if !ok {
+				return nil, nil
+			}
+			return power, nil
+		},
+	})
+}
+
+// addIntensityPaceField adds the pace of the logged in user to intensities, which needs the records of the user.
+func addIntensityPaceField(dbClient database.Client) {
+	intensityType.AddFieldConfig("pace", &graphql.Field{
+		Type:        paceType,
+		Description: "The pace range of the logged in user",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, ok := viewingProfile(p)
+			if !ok {
+				return nil, nil
+			}
+			value, err := currentVdot(p.Context, dbClient, profile, defaultVdotMonths)
+			if err != nil {
+				return nil, err
+			}
+			pace, ok := estimates.New(value).TargetPace(p.Source.(models.Intensity))
+			if !ok {
+				return nil, nil
+			}
+			return pace, nil
+		},
+	})
+}
+
+// viewingProfile is the profile of the logged in user. The second return value is false when nobody is logged in.
+func viewingProfile(p graphql.ResolveParams) (models.Profile, bool) {
+	if authenticated, _ := appcontext.UserAuthenticated(p.Context); !authenticated {
+		return models.Profile{}, false
+	}
+	profile, err := appcontext.Profile(p.Context)
+	return profile, err == nil
+}
+
+func targetRangeField(description string, target func(models.IntensityTargets) models.Range) *graphql.Field {
+	return &graphql.Field{
+		Type:        rangeType,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			r := target(p.Source.(models.IntensityTargets))
+			if !r.IsSet() {
+				return nil, nil
+			}
+			return r, nil
+		},
+	}
+}
+
+// intensityTargetsFromArgument maps an IntensityTargetsInput value to intensity targets.
+func intensityTargetsFromArgument(value interface{}) (models.IntensityTargets, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return models.IntensityTargets{}, errors.New("targets must be an object")
+	}
+
+	var result models.IntensityTargets
+	for name, target := range map[string]*models.Range{
+		"heartRateMax":     &result.HeartRateMax,
+		"heartRateReserve": &result.HeartRateReserve,
+		"vdot":             &result.Vdot,
+		"rpe":              &result.Rpe,
+		"power":            &result.Power,
+	} {
+		r, ok := fields[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		target.Min, _ = r["min"].(float64)
+		target.Max, _ = r["max"].(float64)
+		if target.Min <= 0 || target.Max < target.Min {
+			return models.IntensityTargets{}, errors.New("the " + name + " target must have a positive min no larger than max")
+		}
+	}
+	if result.Rpe.Max > 10 {
+		return models.IntensityTargets{}, errors.New("the rpe target must be on the 1-10 scale")
+	}
+	return result, nil
+}
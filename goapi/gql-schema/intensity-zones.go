@@ -1,8 +1,13 @@
 package gqlschema
 
 import (
+	"errors"
 	"github.com/graphql-go/graphql"
-	"goapi/resolvables/intensity-zones"
+	"goapi/appcontext"
+	"goapi/database"
+	"goapi/gql-common"
+	"goapi/logger"
+	"goapi/models"
 )
 
 var intensityType = graphql.NewObject(
@@ -25,13 +30,229 @@ var intensityZoneFields = graphql.Fields{
 	"coefficient": &graphql.Field{
 		Type: graphql.NewNonNull(graphql.Float),
 	},
+	"order": &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Int),
+	},
 }
 
-func intensityZonesField(resolvableIntensityZones intensityzones.Resolvable) *graphql.Field {
+// intensityZonesField lists the intensities of the logged in user. Nobody logged in has no intensities.
+func intensityZonesField(dbClient database.Client) *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(intensityType))),
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			return resolvableIntensityZones.GetAll(p.Context)
+			authenticated, _ := appcontext.UserAuthenticated(p.Context)
+			if !authenticated {
+				return []models.Intensity{}, nil
+			}
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+			return dbClient.GetIntensitiesForProfile(p.Context, profile.Id)
+		},
+	}
+}
+
+// ownedIntensity fetches an intensity and verifies that it belongs to the logged in user.
+func ownedIntensity(p graphql.ResolveParams, dbClient database.Client, intensityId string) (models.Intensity, error) {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return models.Intensity{}, err
+	}
+
+	intensity, err := dbClient.GetIntensity(p.Context, intensityId)
+	if err != nil {
+		return models.Intensity{}, err
+	}
+	if intensity.CreatedBy != profile.Id {
+		logger.FromContext(p.Context).Warn("The user tried to change an intensity created by someone else")
+		return models.Intensity{}, errNotOwner
+	}
+
+	return intensity, nil
+}
+
+func validCoefficient(coefficient float64) error {
+	if coefficient < 0 {
+		return errors.New("the coefficient can not be negative")
+	}
+	return nil
+}
+
+func createIntensityMutation(dbClient database.Client) *graphql.Field {
+	name := "name"
+	description := "description"
+	coefficient := "coefficient"
+	targets := "targets"
+
+	return &graphql.Field{
+		Type:        intensityType,
+		Description: "Adds an intensity to the end of the logged in user's intensities",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				return nil, err
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = ""
+			}
+			coefficient, err := gqlcommon.GetFloatArgument(p, coefficient)
+			if err != nil {
+				return nil, err
+			}
+			err = validCoefficient(coefficient)
+			if err != nil {
+				return nil, err
+			}
+			var intensityTargets models.IntensityTargets
+			if value, exist := p.Args[targets]; exist {
+				intensityTargets, err = intensityTargetsFromArgument(value)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return dbClient.CreateIntensity(p.Context, profile.Id, name, description, coefficient, intensityTargets)
+		},
+		Args: graphql.FieldConfigArgument{
+			name: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			description: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			coefficient: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			targets: &graphql.ArgumentConfig{
+				Type: intensityTargetsInputType,
+			},
+		},
+	}
+}
+
+func updateIntensityMutation(dbClient database.Client) *graphql.Field {
+	name := "name"
+	description := "description"
+	coefficient := "coefficient"
+	targets := "targets"
+
+	return &graphql.Field{
+		Type: intensityType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			intensity, err := ownedIntensity(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				name = intensity.Name
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = intensity.Description
+			}
+			coefficient, err := gqlcommon.GetFloatArgument(p, coefficient)
+			if err != nil {
+				coefficient = intensity.Coefficient
+			}
+			err = validCoefficient(coefficient)
+			if err != nil {
+				return nil, err
+			}
+			intensityTargets := intensity.Targets
+			if value, exist := p.Args[targets]; exist {
+				intensityTargets, err = intensityTargetsFromArgument(value)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return dbClient.UpdateIntensity(p.Context, id, name, description, coefficient, intensityTargets)
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			name: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			description: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			coefficient: &graphql.ArgumentConfig{
+				Type: graphql.Float,
+			},
+			targets: &graphql.ArgumentConfig{
+				Type:        intensityTargetsInputType,
+				Description: "Replaces all the targets of the intensity",
+			},
+		},
+	}
+}
+
+func deleteIntensityMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Boolean),
+		Description: "Deletes an intensity. Intensities that are used by workouts can not be deleted.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedIntensity(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = dbClient.DeleteIntensity(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
+
+func reorderIntensitiesMutation(dbClient database.Client) *graphql.Field {
+	ids := "ids"
+
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(intensityType))),
+		Description: "Orders the logged in user's intensities as the ids are listed. Every intensity must be listed once.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			ids, err := gqlcommon.GetStringListArgument(p, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.ReorderIntensities(p.Context, profile.Id, ids)
+		},
+		Args: graphql.FieldConfigArgument{
+			ids: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			},
 		},
 	}
 }
@@ -0,0 +1,292 @@
+package gqlschema
+
+import (
+	"context"
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/load"
+	"goapi/models"
+	"goapi/schedule"
+	"time"
+)
+
+var weekLoadType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "WeekLoad",
+		Description: "The training load of a week and how it compares with the weeks before",
+		Fields: graphql.Fields{
+			"week": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "The order of the week in the plan. Empty for weeks of a profile, which can span several plans.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullableInt(p.Source.(weekLoad).Order), nil
+				},
+			},
+			"startDate": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The first date of the week, formatted as YYYY-MM-DD. Empty for weeks of a plan.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					start := p.Source.(weekLoad).Start
+					if start.IsZero() {
+						return nil, nil
+					}
+					return start.Format(dateLayout), nil
+				},
+			},
+			"load": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(weekLoad).Load, nil
+				},
+			},
+			"monotony": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "The average daily load divided by its standard deviation. Above 2 is considered monotonous. Empty when every day has the same load, which is as monotonous as it gets.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullableFloat(p.Source.(weekLoad).Monotony), nil
+				},
+			},
+			"strain": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "The weekly load multiplied by the monotony. Empty when the monotony is.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullableFloat(p.Source.(weekLoad).Strain), nil
+				},
+			},
+			"acuteChronicRatio": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "The load of the week divided by the average load of the last four weeks. Above 1.5 is a load spike. Empty for the first three weeks.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullableFloat(p.Source.(weekLoad).AcuteChronicRatio), nil
+				},
+			},
+		}})
+
+// weekLoad is the load of a week, with the order of the week in its plan for weeks of a plan, and the start
+// date for weeks of a profile.
+type weekLoad struct {
+	Order *int
+	Start time.Time
+	load.Week
+}
+
+func nullableInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+func nullableFloat(value *float64) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// nullableLoad resolves unknown loads to null.
+func nullableLoad(value float64, ok bool, err error) (interface{}, error) {
+	if err != nil || !ok {
+		return nil, err
+	}
+	return value, nil
+}
+
+func workoutLoad(ctx context.Context, dbClient database.Client, estimator estimates.Estimator, workout models.Workout) (float64, bool, error) {
+	parts, err := dbClient.GetWorkoutPartsForWorkout(ctx, workout.Id)
+	if err != nil {
+		return 0, false, err
+	}
+	value, ok := load.Parts(estimator, parts)
+	return value, ok, nil
+}
+
+func dayLoad(ctx context.Context, dbClient database.Client, estimator estimates.Estimator, day models.Day) (float64, bool, error) {
+	workouts, err := dbClient.GetWorkoutsForDay(ctx, day.Id)
+	if err != nil {
+		return 0, false, err
+	}
+
+	total := 0.0
+	for _, workout := range workouts {
+		value, ok, err := workoutLoad(ctx, dbClient, estimator, workout)
+		if err != nil || !ok {
+			return 0, false, err
+		}
+		total += value
+	}
+	return total, true, nil
+}
+
+// dailyLoads lists the load of every day of the weeks, with rest days as 0.
+func dailyLoads(ctx context.Context, dbClient database.Client, estimator estimates.Estimator, weeks []models.Week) ([]float64, bool, error) {
+	loads := make([]float64, 7*len(weeks))
+	for i, week := range weeks {
+		days, err := dbClient.GetDaysForWeek(ctx, week.Id)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, day := range days {
+			value, ok, err := dayLoad(ctx, dbClient, estimator, day)
+			if err != nil || !ok {
+				return nil, false, err
+			}
+			loads[7*i+day.Day] = value
+		}
+	}
+	return loads, true, nil
+}
+
+func weekLoadField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.Float,
+		Description: "The sum of the load of the week's workouts",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			week := p.Source.(models.Week)
+			plan, err := dbClient.GetPlan(p.Context, week.PlanId)
+			if err != nil {
+				return nil, err
+			}
+			estimator, err := viewerEstimator(p, dbClient, plan.CreatedBy)
+			if err != nil {
+				return nil, err
+			}
+
+			loads, ok, err := dailyLoads(p.Context, dbClient, estimator, []models.Week{week})
+			if err != nil || !ok {
+				return nil, err
+			}
+			return load.Weeks(loads)[0].Load, nil
+		},
+	}
+}
+
+func planLoadField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.Float,
+		Description: "The sum of the load of all the workouts in the plan",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			weeks, ok, err := planWeekLoads(p, dbClient, p.Source.(models.Plan))
+			if err != nil || !ok {
+				return nil, err
+			}
+
+			total := 0.0
+			for _, week := range weeks {
+				total += week.Load
+			}
+			return total, nil
+		},
+	}
+}
+
+func planLoadProgressionField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewList(graphql.NewNonNull(weekLoadType)),
+		Description: "The load of every week of the plan, with the acute:chronic workload ratio, monotony and strain. Empty if any workout can not be estimated.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			weeks, ok, err := planWeekLoads(p, dbClient, p.Source.(models.Plan))
+			if err != nil || !ok {
+				return nil, err
+			}
+			return weeks, nil
+		},
+	}
+}
+
+func planWeekLoads(p graphql.ResolveParams, dbClient database.Client, plan models.Plan) ([]weekLoad, bool, error) {
+	estimator, err := viewerEstimator(p, dbClient, plan.CreatedBy)
+	if err != nil {
+		return nil, false, err
+	}
+	weeks, err := dbClient.GetWeeksForPlan(p.Context, plan.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	loads, ok, err := dailyLoads(p.Context, dbClient, estimator, weeks)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	weekLoads := make([]weekLoad, 0, len(weeks))
+	for i, week := range load.Weeks(loads) {
+		order := weeks[i].Order
+		weekLoads = append(weekLoads, weekLoad{Order: &order, Week: week})
+	}
+	return weekLoads, true, nil
+}
+
+// profileLoadProgressionField resolves the load of the scheduled training of a profile, in weeks from Monday.
+// The three weeks before the period are taken into account, so the acute:chronic ratio is known from the first
+// week. Only the owner of the profile can see it.
+func profileLoadProgressionField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewList(graphql.NewNonNull(weekLoadType)),
+		Description: "The load of the scheduled plans in weeks from Monday, with the acute:chronic workload ratio, monotony and strain. Empty if any workout can not be estimated. Only visible to the owner.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			if err := ownProfile(p, profile); err != nil {
+				return nil, err
+			}
+			from, to, err := dateRangeArguments(p)
+			if err != nil {
+				return nil, err
+			}
+			estimator, err := viewerEstimator(p, dbClient, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			schedules, err := dbClient.GetSchedulesForProfile(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			daily := map[time.Time]float64{}
+			var first, last time.Time
+			for _, planSchedule := range schedules {
+				days, err := schedule.Days(p.Context, dbClient, planSchedule)
+				if err != nil {
+					return nil, err
+				}
+				for _, day := range days {
+					value, ok, err := dayLoad(p.Context, dbClient, estimator, day.Day)
+					if err != nil || !ok {
+						return nil, err
+					}
+					daily[day.Date] += value
+					if first.IsZero() || day.Date.Before(first) {
+						first = day.Date
+					}
+					if day.Date.After(last) {
+						last = day.Date
+					}
+				}
+			}
+
+			if from.IsZero() {
+				from = first
+			}
+			if to.IsZero() {
+				to = last.AddDate(0, 0, 1)
+			}
+			weekLoads := []weekLoad{}
+			if len(daily) == 0 || !from.Before(to) {
+				return weekLoads, nil
+			}
+			start := from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+			origin := start.AddDate(0, 0, -7*(load.ChronicWeeks-1))
+			loads := []float64{}
+			for date := origin; date.Before(to); date = date.AddDate(0, 0, 1) {
+				loads = append(loads, daily[date])
+			}
+			for i, week := range load.Weeks(loads) {
+				if i >= load.ChronicWeeks-1 {
+					weekLoads = append(weekLoads, weekLoad{Start: origin.AddDate(0, 0, 7*i), Week: week})
+				}
+			}
+			return weekLoads, nil
+		},
+		Args: dateRangeArgumentConfig(),
+	}
+}
@@ -0,0 +1,97 @@
+package gqlschema
+
+import (
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/gql-common"
+	"goapi/models"
+	"goapi/notation"
+)
+
+// notationError adds the position of a parse error to the extensions of the GraphQL error.
+type notationError struct {
+	*notation.ParseError
+}
+
+func (e notationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   "NOTATION_PARSE_ERROR",
+		"offset": e.Offset,
+		"column": e.Offset + 1,
+	}
+}
+
+func workoutNotationField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.String),
+		Description: "The workout written in the text notation, like 10min E + 4x(4min I / 3min E) + 10min E",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			workout := p.Source.(models.Workout)
+			parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, workout.Id)
+			if err != nil {
+				return nil, err
+			}
+			intensities, err := dbClient.GetIntensitiesForProfile(p.Context, workout.CreatedBy)
+			if err != nil {
+				return nil, err
+			}
+			return notation.Format(parts, intensities), nil
+		},
+	}
+}
+
+func createWorkoutFromTextMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
+	name := "name"
+	description := "description"
+	text := "text"
+
+	return &graphql.Field{
+		Type: workoutType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				return nil, err
+			}
+			text, err := gqlcommon.GetStringArgument(p, text)
+			if err != nil {
+				return nil, err
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = text
+			}
+
+			intensities, err := dbClient.GetIntensitiesForProfile(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			parts, err := notation.Parse(text, intensities)
+			if err != nil {
+				if parseError, ok := err.(*notation.ParseError); ok {
+					return nil, notationError{parseError}
+				}
+				return nil, err
+			}
+
+			return dbClient.CreateWorkout(p.Context, name, description, parts, profile.Id)
+		},
+		Args: graphql.FieldConfigArgument{
+			name: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			description: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "Defaults to the text of the workout",
+			},
+			text: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The workout in the text notation, like 10min E + 4x(4min I / 3min E) + 10min E",
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package gqlschema
+
+import (
+	"github.com/graphql-go/graphql"
+	"goapi/vdot"
+	"math"
+)
+
+var (
+	paceType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "Pace",
+			Description: "A pace range in seconds per kilometer",
+			Fields: graphql.Fields{
+				"fastest": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(vdot.Pace).Fastest)), nil
+					},
+				},
+				"slowest": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(vdot.Pace).Slowest)), nil
+					},
+				},
+			}})
+
+	trainingPacesType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: "TrainingPaces",
+			Fields: graphql.Fields{
+				"easy": &graphql.Field{
+					Type: graphql.NewNonNull(paceType),
+				},
+				"marathon": &graphql.Field{
+					Type: graphql.NewNonNull(paceType),
+				},
+				"threshold": &graphql.Field{
+					Type: graphql.NewNonNull(paceType),
+				},
+				"interval": &graphql.Field{
+					Type: graphql.NewNonNull(paceType),
+				},
+				"repetition": &graphql.Field{
+					Type: graphql.NewNonNull(paceType),
+				},
+			}})
+)
+
+func vdotFromRaceField() *graphql.Field {
+	distance := "distance"
+	duration := "duration"
+
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Float),
+		Description: "The VDOT of a race result",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			distance, _ := p.Args[distance].(int)
+			duration, _ := p.Args[duration].(int)
+			return vdot.FromRace(float64(distance), float64(duration)), nil
+		},
+		Args: graphql.FieldConfigArgument{
+			distance: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The distance of the race in meters",
+			},
+			duration: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The finishing time in seconds",
+			},
+		},
+	}
+}
+
+func pacesField() *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(trainingPacesType),
+		Description: "The training paces of a VDOT",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			value, _ := p.Args["vdot"].(float64)
+			return vdot.TrainingPaces(value), nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"vdot": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+		},
+	}
+}
@@ -1,13 +1,15 @@
 package gqlschema
 
 import (
+	"errors"
 	"github.com/graphql-go/graphql"
+	"goapi/database"
 	"goapi/gql-common"
-	"goapi/resolvables/plans"
-	"goapi/resolvables/weeks"
+	"goapi/logger"
+	"goapi/models"
 )
 
-func planFields(resolvableWeeks weeks.Resolvable, weekType *graphql.Object) graphql.Fields {
+func planFields(dbClient database.Client, weekType *graphql.Object, profileType *graphql.Object) graphql.Fields {
 	return graphql.Fields{
 		"id": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.String),
@@ -21,31 +23,39 @@ func planFields(resolvableWeeks weeks.Resolvable, weekType *graphql.Object) grap
 		"weeks": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(weekType))),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				return resolvableWeeks.GetByParentId(p.Context, p.Source.(plans.Plan).Id)
+				return dbClient.GetWeeksForPlan(p.Context, p.Source.(models.Plan).Id)
+			},
+		},
+		"load":            planLoadField(dbClient),
+		"loadProgression": planLoadProgressionField(dbClient),
+		"createdBy": &graphql.Field{
+			Type: graphql.NewNonNull(profileType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return dbClient.GetProfile(p.Context, p.Source.(models.Plan).CreatedBy)
 			},
 		},
 	}
 }
 
-func planType(resolvableWeeks weeks.Resolvable, weekType *graphql.Object) *graphql.Object {
+func planType(dbClient database.Client, weekType *graphql.Object, profileType *graphql.Object) *graphql.Object {
 	return graphql.NewObject(
 		graphql.ObjectConfig{
 			Name:   "Plan",
-			Fields: planFields(resolvableWeeks, weekType),
+			Fields: planFields(dbClient, weekType, profileType),
 		},
 	)
 }
 
-func plansField(resolvablePlan plans.Resolvable, planType *graphql.Object) *graphql.Field {
+func plansField(dbClient database.Client, planType *graphql.Object) *graphql.Field {
 	return &graphql.Field{
-		Type:   graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(planType))),
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(planType))),
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			return resolvablePlan.GetAll(p.Context)
+			return dbClient.GetPlans(p.Context)
 		},
 	}
 }
 
-func planField(resolvablePlan plans.Resolvable, planType *graphql.Object) *graphql.Field {
+func planField(dbClient database.Client, planType *graphql.Object) *graphql.Field {
 	return &graphql.Field{
 		Type: planType,
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
@@ -53,12 +63,287 @@ func planField(resolvablePlan plans.Resolvable, planType *graphql.Object) *graph
 			if err != nil {
 				return nil, err
 			}
-			return resolvablePlan.Get(p.Context, id)
+			return dbClient.GetPlan(p.Context, id)
 		},
 		Args: map[string]*graphql.ArgumentConfig{
 			"id": {
-				Type:         graphql.NewNonNull(graphql.String),
-				Description:  "The id of the plan",
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "The id of the plan",
+			},
+		},
+	}
+}
+
+// ownedPlan fetches a plan and verifies that it was created by the logged in user.
+func ownedPlan(p graphql.ResolveParams, dbClient database.Client, planId string) (models.Plan, error) {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return models.Plan{}, err
+	}
+
+	plan, err := dbClient.GetPlan(p.Context, planId)
+	if err != nil {
+		return models.Plan{}, err
+	}
+	if plan.CreatedBy != profile.Id {
+		logger.FromContext(p.Context).Warn("The user tried to change a plan created by someone else")
+		return models.Plan{}, errNotOwner
+	}
+
+	return plan, nil
+}
+
+// ownedWeek fetches a week and verifies that its plan was created by the logged in user.
+func ownedWeek(p graphql.ResolveParams, dbClient database.Client, weekId string) (models.Week, error) {
+	week, err := dbClient.GetWeek(p.Context, weekId)
+	if err != nil {
+		return models.Week{}, err
+	}
+
+	_, err = ownedPlan(p, dbClient, week.PlanId)
+	if err != nil {
+		return models.Week{}, err
+	}
+
+	return week, nil
+}
+
+func createPlanMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	name := "name"
+	description := "description"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				return nil, err
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = ""
+			}
+
+			return dbClient.CreatePlan(p.Context, name, description, profile.Id)
+		},
+		Args: graphql.FieldConfigArgument{
+			name: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			description: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+	}
+}
+
+func updatePlanMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	name := "name"
+	description := "description"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			plan, err := ownedPlan(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				name = plan.Name
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = plan.Description
+			}
+
+			return dbClient.UpdatePlan(p.Context, id, name, description)
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			name: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			description: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+	}
+}
+
+func deletePlanMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedPlan(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = dbClient.DeletePlan(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
+
+func addWeekMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	planId := "planId"
+	order := "order"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			planId, err := gqlcommon.GetStringArgument(p, planId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedPlan(p, dbClient, planId)
+			if err != nil {
+				return nil, err
+			}
+
+			order, ok := p.Args[order].(int)
+			if !ok {
+				order = -1
+			}
+
+			return dbClient.AddWeek(p.Context, planId, order)
+		},
+		Args: graphql.FieldConfigArgument{
+			planId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			order: &graphql.ArgumentConfig{
+				Type:        graphql.Int,
+				Description: "Where to insert the week. The week is added to the end of the plan if omitted.",
+			},
+		},
+	}
+}
+
+func moveWeekMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	weekId := "weekId"
+	order := "order"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			weekId, err := gqlcommon.GetStringArgument(p, weekId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWeek(p, dbClient, weekId)
+			if err != nil {
+				return nil, err
+			}
+			order, err := gqlcommon.GetIntArgument(p, order)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.MoveWeek(p.Context, weekId, order)
+		},
+		Args: graphql.FieldConfigArgument{
+			weekId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			order: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	}
+}
+
+func removeWeekMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	weekId := "weekId"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			weekId, err := gqlcommon.GetStringArgument(p, weekId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWeek(p, dbClient, weekId)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.RemoveWeek(p.Context, weekId)
+		},
+		Args: graphql.FieldConfigArgument{
+			weekId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
+
+func setDayWorkoutsMutation(dbClient database.Client, planType *graphql.Object) *graphql.Field {
+	weekId := "weekId"
+	day := "day"
+	workoutIds := "workoutIds"
+
+	return &graphql.Field{
+		Type: planType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			weekId, err := gqlcommon.GetStringArgument(p, weekId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWeek(p, dbClient, weekId)
+			if err != nil {
+				return nil, err
+			}
+			day, err := gqlcommon.GetIntArgument(p, day)
+			if err != nil {
+				return nil, err
+			}
+			if day < 0 || day > 6 {
+				return nil, errors.New("day must be between 0 and 6")
+			}
+			workoutIds, err := gqlcommon.GetStringListArgument(p, workoutIds)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.SetDayWorkouts(p.Context, weekId, day, workoutIds)
+		},
+		Args: graphql.FieldConfigArgument{
+			weekId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			day: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The day of the week, starting at 0",
+			},
+			workoutIds: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+				Description: "The workouts of the day, in order. An empty list clears the day.",
 			},
 		},
 	}
@@ -0,0 +1,99 @@
+package gqlschema
+
+import (
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/models"
+	"goapi/predictions"
+	"goapi/vdot"
+	"math"
+	"time"
+)
+
+const (
+	predictionMethodVdot   = "vdot"
+	predictionMethodRiegel = "riegel"
+)
+
+var (
+	predictionMethod = graphql.NewEnum(graphql.EnumConfig{
+		Name: "PredictionMethod",
+		Values: graphql.EnumValueConfigMap{
+			"VDOT": &graphql.EnumValueConfig{
+				Value:       predictionMethodVdot,
+				Description: "Times giving the same VDOT as the current VDOT of the profile",
+			},
+			"RIEGEL": &graphql.EnumValueConfig{
+				Value:       predictionMethodRiegel,
+				Description: "Riegel's formula applied to the best recent record of the profile",
+			},
+		},
+	})
+
+	racePredictionType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: "RacePrediction",
+			Fields: graphql.Fields{
+				"race": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(predictions.Prediction).Race.Name, nil
+					},
+				},
+				"distance": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The distance of the race in meters",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(predictions.Prediction).Race.Meters)), nil
+					},
+				},
+				"duration": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The predicted finishing time in seconds",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return int(math.Round(p.Source.(predictions.Prediction).Seconds)), nil
+					},
+				},
+				"pace": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "The predicted pace in seconds per kilometer",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						prediction := p.Source.(predictions.Prediction)
+						return int(math.Round(prediction.Seconds / (prediction.Race.Meters / 1000))), nil
+					},
+				},
+			}})
+)
+
+func racePredictionsField(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(racePredictionType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+
+			if method, _ := p.Args["method"].(string); method == predictionMethodRiegel {
+				records, err := dbClient.GetRecords(p.Context, profile.Id)
+				if err != nil {
+					return nil, err
+				}
+				record, found := vdot.BestRecord(records, time.Now().AddDate(0, -defaultVdotMonths, 0))
+				if !found {
+					record, _ = vdot.BestRecord(records, time.Time{})
+				}
+				return predictions.ByRiegel(record), nil
+			}
+
+			value, err := currentVdot(p.Context, dbClient, profile, defaultVdotMonths)
+			if err != nil {
+				return nil, err
+			}
+			return predictions.ByVdot(value), nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"method": &graphql.ArgumentConfig{
+				Type:         predictionMethod,
+				DefaultValue: predictionMethodVdot,
+			},
+		},
+	}
+}
@@ -1,15 +1,19 @@
 package gqlschema
 
 import (
+	"context"
 	"errors"
 	"github.com/graphql-go/graphql"
-	"goapi/appcontext"
 	"goapi/database"
 	"goapi/gql-common"
-	"goapi/logger"
 	"goapi/models"
+	"goapi/vdot"
+	"time"
 )
 
+// defaultVdotMonths is how far back records count towards the current VDOT of a profile.
+const defaultVdotMonths = vdot.RecentMonths
+
 func profileFields(dbClient database.Client, recordType *graphql.Object) graphql.Fields {
 	return graphql.Fields{
 		"id": &graphql.Field{
@@ -24,6 +28,44 @@ func profileFields(dbClient database.Client, recordType *graphql.Object) graphql
 		"vdot": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"maxHeartRate": optionalIntField(func(profile models.Profile) int {
+			return profile.MaxHeartRate
+		}),
+		"restingHeartRate": optionalIntField(func(profile models.Profile) int {
+			return profile.RestingHeartRate
+		}),
+		"thresholdPower": optionalIntField(func(profile models.Profile) int {
+			return profile.ThresholdPower
+		}),
+		"currentVdot": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Float),
+			Description: "The best VDOT of the records set within the last months, or the VDOT of the profile if there are none",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				months, ok := p.Args["months"].(int)
+				if !ok {
+					months = defaultVdotMonths
+				}
+				return currentVdot(p.Context, dbClient, p.Source.(models.Profile), months)
+			},
+			Args: graphql.FieldConfigArgument{
+				"months": &graphql.ArgumentConfig{
+					Type:         graphql.Int,
+					DefaultValue: defaultVdotMonths,
+				},
+			},
+		},
+		"paces": &graphql.Field{
+			Type:        trainingPacesType,
+			Description: "The training paces of the profile's current VDOT",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				value, err := currentVdot(p.Context, dbClient, p.Source.(models.Profile), defaultVdotMonths)
+				if err != nil || value <= 0 {
+					return nil, err
+				}
+				return vdot.TrainingPaces(value), nil
+			},
+		},
+		"racePredictions": racePredictionsField(dbClient),
 		"records": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(recordType))),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
@@ -33,6 +75,30 @@ func profileFields(dbClient database.Client, recordType *graphql.Object) graphql
 	}
 }
 
+// optionalIntField resolves a value of the profile that is not set when it is 0.
+func optionalIntField(value func(models.Profile) int) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.Int,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if value := value(p.Source.(models.Profile)); value > 0 {
+				return value, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// currentVdot is the best VDOT of the records set within the last months. Profiles without recent records
+// keep the VDOT set on the profile.
+func currentVdot(ctx context.Context, dbClient database.Client, profile models.Profile, months int) (float64, error) {
+	records, err := dbClient.GetRecords(ctx, profile.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	return vdot.Current(profile, records, time.Now().AddDate(0, -months, 0)), nil
+}
+
 func profileType(dbClient database.Client, recordType *graphql.Object) *graphql.Object {
 	return graphql.NewObject(
 		graphql.ObjectConfig{
@@ -74,19 +140,72 @@ func meField(profileType *graphql.Object) *graphql.Field {
 	return &graphql.Field{
 		Type: profileType,
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			log := logger.FromContext(p.Context)
-			authenticated, err := appcontext.UserAuthenticated(p.Context)
-			if err != nil || !authenticated {
-				log.Error("The user must be logged in to use this query")
-				return nil, errors.New("the user must be logged in to use this query")
+			return authenticatedProfile(p)
+		},
+	}
+}
+
+func updateProfileMutation(dbClient database.Client, profileType *graphql.Object) *graphql.Field {
+	firstname := "firstname"
+	lastname := "lastname"
+	vdot := "vdot"
+	maxHeartRate := "maxHeartRate"
+	restingHeartRate := "restingHeartRate"
+	thresholdPower := "thresholdPower"
+
+	return &graphql.Field{
+		Type:        profileType,
+		Description: "Updates the profile of the logged in user. Values that are left out are kept, and 0 unsets a value.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			if firstname, err := gqlcommon.GetStringArgument(p, firstname); err == nil {
+				profile.FirstName = firstname
 			}
-			profile, err := appcontext.Profile(p.Context)
-			if err != nil || !authenticated {
-				log.Error("Profile expected to be on Context, but was not found.")
-				return nil, errors.New("unexpected error")
+			if lastname, err := gqlcommon.GetStringArgument(p, lastname); err == nil {
+				profile.LastName = lastname
+			}
+			for key, value := range map[string]*int{
+				vdot:             &profile.Vdot,
+				maxHeartRate:     &profile.MaxHeartRate,
+				restingHeartRate: &profile.RestingHeartRate,
+				thresholdPower:   &profile.ThresholdPower,
+			} {
+				if argument, exist := p.Args[key].(int); exist {
+					if argument < 0 {
+						return nil, errors.New(key + " can not be negative")
+					}
+					*value = argument
+				}
+			}
+			if profile.MaxHeartRate > 0 && profile.RestingHeartRate >= profile.MaxHeartRate {
+				return nil, errors.New("the resting heart rate must be lower than the max heart rate")
 			}
 
-			return profile, nil
+			return dbClient.UpdateProfile(p.Context, profile)
+		},
+		Args: graphql.FieldConfigArgument{
+			firstname: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			lastname: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			vdot: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			maxHeartRate: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			restingHeartRate: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			thresholdPower: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
 		},
 	}
-}
\ No newline at end of file
+}
@@ -2,8 +2,15 @@ package gqlschema
 
 import (
 	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/gql-common"
+	"goapi/models"
+	"goapi/vdot"
+	"time"
 )
 
+const dateLayout = "2006-01-02"
+
 func recordFields() graphql.Fields {
 	return graphql.Fields{
 		"id": &graphql.Field{
@@ -12,9 +19,41 @@ func recordFields() graphql.Fields {
 		"race": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.String),
 		},
+		"distance": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "The distance of the race in meters",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				record := p.Source.(models.Record)
+				if record.Distance == 0 {
+					return nil, nil
+				}
+				return record.Distance, nil
+			},
+		},
 		"duration": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"date": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The date the record was set, formatted as YYYY-MM-DD",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				record := p.Source.(models.Record)
+				if record.Date.IsZero() {
+					return nil, nil
+				}
+				return record.Date.Format(dateLayout), nil
+			},
+		},
+		"vdot": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				value := vdot.OfRecord(p.Source.(models.Record))
+				if value == 0 {
+					return nil, nil
+				}
+				return value, nil
+			},
+		},
 	}
 }
 
@@ -26,3 +65,94 @@ func recordType() *graphql.Object {
 		},
 	)
 }
+
+func addRecordMutation(dbClient database.Client, recordType *graphql.Object) *graphql.Field {
+	race := "race"
+	distance := "distance"
+	duration := "duration"
+	date := "date"
+
+	return &graphql.Field{
+		Type: recordType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			race, err := gqlcommon.GetStringArgument(p, race)
+			if err != nil {
+				return nil, err
+			}
+			distance, err := gqlcommon.GetIntArgument(p, distance)
+			if err != nil {
+				return nil, err
+			}
+			duration, err := gqlcommon.GetIntArgument(p, duration)
+			if err != nil {
+				return nil, err
+			}
+			var recordDate time.Time
+			if value, err := gqlcommon.GetStringArgument(p, date); err == nil {
+				recordDate, err = time.Parse(dateLayout, value)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			return dbClient.AddRecord(p.Context, profile.Id, race, distance, duration, recordDate)
+		},
+		Args: graphql.FieldConfigArgument{
+			race: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			distance: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The distance of the race in meters",
+			},
+			duration: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The finishing time in seconds",
+			},
+			date: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The date of the race, formatted as YYYY-MM-DD",
+			},
+		},
+	}
+}
+
+func deleteRecordMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+
+			record, err := dbClient.GetRecord(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			if record.ProfileId != profile.Id {
+				return nil, errNotOwner
+			}
+
+			err = dbClient.DeleteRecord(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
@@ -0,0 +1,420 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/gql-common"
+	"goapi/logger"
+	"goapi/models"
+	"goapi/schedule"
+	"sort"
+	"time"
+)
+
+const defaultTimeZone = "UTC"
+
+var errPrivateSchedules = errors.New("the schedules of a profile are only visible to its owner")
+
+func planScheduleFields(dbClient database.Client, planType *graphql.Object) graphql.Fields {
+	return graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"plan": &graphql.Field{
+			Type: graphql.NewNonNull(planType),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return dbClient.GetPlan(p.Context, p.Source.(models.PlanSchedule).PlanId)
+			},
+		},
+		"startDate": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "The date of the first scheduled day, formatted as YYYY-MM-DD",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				planSchedule := p.Source.(models.PlanSchedule)
+				weeks, err := dbClient.GetWeeksForPlan(p.Context, planSchedule.PlanId)
+				if err != nil {
+					return nil, err
+				}
+				return schedule.Start(planSchedule, len(weeks)).Format(dateLayout), nil
+			},
+		},
+		"endDate": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "The date of the last scheduled day, formatted as YYYY-MM-DD",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				planSchedule := p.Source.(models.PlanSchedule)
+				weeks, err := dbClient.GetWeeksForPlan(p.Context, planSchedule.PlanId)
+				if err != nil {
+					return nil, err
+				}
+				return schedule.End(planSchedule, len(weeks)).Format(dateLayout), nil
+			},
+		},
+		"raceDate": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The date of the race the plan ends on, formatted as YYYY-MM-DD, for schedules that count back from a race",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				planSchedule := p.Source.(models.PlanSchedule)
+				if planSchedule.RaceDate.IsZero() {
+					return nil, nil
+				}
+				return planSchedule.RaceDate.Format(dateLayout), nil
+			},
+		},
+		"timeZone": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"skippedWeeks": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+			Description: "The orders of the plan weeks that are left out",
+		},
+	}
+}
+
+func planScheduleType(dbClient database.Client, planType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "PlanSchedule",
+			Description: "A plan placed on the calendar, either from a start date or counting back from a race",
+			Fields:      planScheduleFields(dbClient, planType),
+		},
+	)
+}
+
+func calendarDayType(dbClient database.Client, planScheduleType, weekType, dayType, workoutV2Type *graphql.Object) *graphql.Object {
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "CalendarDay",
+			Description: "A day of a scheduled plan on its calendar date",
+			Fields: graphql.Fields{
+				"date": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "The date, formatted as YYYY-MM-DD",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(schedule.Day).Date.Format(dateLayout), nil
+					},
+				},
+				"schedule": &graphql.Field{
+					Type: graphql.NewNonNull(planScheduleType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(schedule.Day).Schedule, nil
+					},
+				},
+				"week": &graphql.Field{
+					Type: graphql.NewNonNull(weekType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(schedule.Day).Week, nil
+					},
+				},
+				"day": &graphql.Field{
+					Type: graphql.NewNonNull(dayType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(schedule.Day).Day, nil
+					},
+				},
+				"workouts": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workoutV2Type))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return dbClient.GetWorkoutsForDay(p.Context, p.Source.(schedule.Day).Day.Id)
+					},
+				},
+			},
+		},
+	)
+}
+
+// ownProfile verifies that the profile is the one of the logged in user.
+func ownProfile(p graphql.ResolveParams, profile models.Profile) error {
+	viewer, err := authenticatedProfile(p)
+	if err != nil {
+		return err
+	}
+	if viewer.Id != profile.Id {
+		return errPrivateSchedules
+	}
+	return nil
+}
+
+func profileSchedulesField(dbClient database.Client, planScheduleType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(planScheduleType))),
+		Description: "The plans on the calendar of the profile. Only visible to the owner.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			if err := ownProfile(p, profile); err != nil {
+				return nil, err
+			}
+			return dbClient.GetSchedulesForProfile(p.Context, profile.Id)
+		},
+	}
+}
+
+// profileCalendarField resolves the days of the scheduled plans between two dates. Only the owner of the
+// profile can see its calendar.
+func profileCalendarField(dbClient database.Client, calendarDayType *graphql.Object) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(calendarDayType))),
+		Description: "The planned days of the scheduled plans, in date order",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile := p.Source.(models.Profile)
+			if err := ownProfile(p, profile); err != nil {
+				return nil, err
+			}
+			from, to, err := dateRangeArguments(p)
+			if err != nil {
+				return nil, err
+			}
+
+			schedules, err := dbClient.GetSchedulesForProfile(p.Context, profile.Id)
+			if err != nil {
+				return nil, err
+			}
+			calendar := []schedule.Day{}
+			for _, planSchedule := range schedules {
+				days, err := schedule.Days(p.Context, dbClient, planSchedule)
+				if err != nil {
+					return nil, err
+				}
+				for _, day := range days {
+					if (from.IsZero() || !day.Date.Before(from)) && (to.IsZero() || day.Date.Before(to)) {
+						calendar = append(calendar, day)
+					}
+				}
+			}
+			sort.SliceStable(calendar, func(i, j int) bool {
+				return calendar[i].Date.Before(calendar[j].Date)
+			})
+			return calendar, nil
+		},
+		Args: dateRangeArgumentConfig(),
+	}
+}
+
+// ownedSchedule fetches a schedule and verifies that it belongs to the logged in user.
+func ownedSchedule(p graphql.ResolveParams, dbClient database.Client, scheduleId string) (models.PlanSchedule, error) {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return models.PlanSchedule{}, err
+	}
+
+	planSchedule, err := dbClient.GetSchedule(p.Context, scheduleId)
+	if err != nil {
+		return models.PlanSchedule{}, err
+	}
+	if planSchedule.ProfileId != profile.Id {
+		logger.FromContext(p.Context).Warn("The user tried to change a schedule of someone else")
+		return models.PlanSchedule{}, errNotOwner
+	}
+
+	return planSchedule, nil
+}
+
+// dateArgument parses an optional date argument. The second return value is false when it is left out.
+func dateArgument(p graphql.ResolveParams, name string) (time.Time, bool, error) {
+	value, err := gqlcommon.GetStringArgument(p, name)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, false, errors.New(name + " must be formatted as YYYY-MM-DD")
+	}
+	return date, true, nil
+}
+
+func assignPlanMutation(dbClient database.Client, planScheduleType *graphql.Object) *graphql.Field {
+	planId := "planId"
+	startDate := "startDate"
+	raceDate := "raceDate"
+	timeZone := "timeZone"
+
+	return &graphql.Field{
+		Type:        planScheduleType,
+		Description: "Places a plan on the calendar of the logged in user, either from a start date or ending on a race date",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
+			}
+
+			planId, err := gqlcommon.GetStringArgument(p, planId)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := dbClient.GetPlan(p.Context, planId); err != nil {
+				return nil, err
+			}
+			planSchedule := models.PlanSchedule{ProfileId: profile.Id, PlanId: planId, TimeZone: defaultTimeZone}
+
+			start, hasStart, err := dateArgument(p, startDate)
+			if err != nil {
+				return nil, err
+			}
+			race, hasRace, err := dateArgument(p, raceDate)
+			if err != nil {
+				return nil, err
+			}
+			if hasStart == hasRace {
+				return nil, errors.New("either startDate or raceDate must be given")
+			}
+			planSchedule.StartDate, planSchedule.RaceDate = start, race
+
+			if value, err := gqlcommon.GetStringArgument(p, timeZone); err == nil {
+				if _, err := time.LoadLocation(value); err != nil {
+					return nil, errors.New("timeZone must be an IANA time zone, like Europe/Oslo")
+				}
+				planSchedule.TimeZone = value
+			}
+
+			return dbClient.CreateSchedule(p.Context, planSchedule)
+		},
+		Args: graphql.FieldConfigArgument{
+			planId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			startDate: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The date of the first day of the plan, formatted as YYYY-MM-DD",
+			},
+			raceDate: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The date of the race on the last day of the plan, formatted as YYYY-MM-DD",
+			},
+			timeZone: &graphql.ArgumentConfig{
+				Type:         graphql.String,
+				DefaultValue: defaultTimeZone,
+				Description:  "The IANA time zone of the athlete, like Europe/Oslo",
+			},
+		},
+	}
+}
+
+func shiftScheduleMutation(dbClient database.Client, planScheduleType *graphql.Object) *graphql.Field {
+	days := "days"
+
+	return &graphql.Field{
+		Type:        planScheduleType,
+		Description: "Moves a scheduled plan, with its start or race date, a number of days later, or earlier for negative days",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			planSchedule, err := ownedSchedule(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+			days, err := gqlcommon.GetIntArgument(p, days)
+			if err != nil {
+				return nil, err
+			}
+
+			if planSchedule.RaceDate.IsZero() {
+				planSchedule.StartDate = planSchedule.StartDate.AddDate(0, 0, days)
+			} else {
+				planSchedule.RaceDate = planSchedule.RaceDate.AddDate(0, 0, days)
+			}
+			return dbClient.UpdateSchedule(p.Context, planSchedule)
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			days: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+	}
+}
+
+func skipWeekMutation(dbClient database.Client, planScheduleType *graphql.Object) *graphql.Field {
+	weekOrder := "weekOrder"
+	skip := "skip"
+
+	return &graphql.Field{
+		Type: planScheduleType,
+		Description: "Leaves a week of the plan out of the schedule, or puts it back. The other weeks move to close the gap, " +
+			"keeping the start date, or the race date of schedules that count back from a race.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			planSchedule, err := ownedSchedule(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+			weekOrder, err := gqlcommon.GetIntArgument(p, weekOrder)
+			if err != nil {
+				return nil, err
+			}
+			weeks, err := dbClient.GetWeeksForPlan(p.Context, planSchedule.PlanId)
+			if err != nil {
+				return nil, err
+			}
+			if weekOrder < 0 || weekOrder >= len(weeks) {
+				return nil, database.ErrInvalidWeekOrder
+			}
+			skip, ok := p.Args[skip].(bool)
+			if !ok {
+				skip = true
+			}
+
+			skippedWeeks := []int{}
+			for _, skipped := range planSchedule.SkippedWeeks {
+				if skipped != weekOrder {
+					skippedWeeks = append(skippedWeeks, skipped)
+				}
+			}
+			if skip {
+				skippedWeeks = append(skippedWeeks, weekOrder)
+			}
+			planSchedule.SkippedWeeks = skippedWeeks
+
+			return dbClient.UpdateSchedule(p.Context, planSchedule)
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			weekOrder: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "The order of the week in the plan",
+			},
+			skip: &graphql.ArgumentConfig{
+				Type:         graphql.Boolean,
+				DefaultValue: true,
+				Description:  "False puts a skipped week back",
+			},
+		},
+	}
+}
+
+func unassignPlanMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.Boolean),
+		Description: "Removes a scheduled plan from the calendar",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedSchedule(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = dbClient.DeleteSchedule(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
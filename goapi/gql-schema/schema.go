@@ -3,54 +3,89 @@ package gqlschema
 import (
 	"github.com/graphql-go/graphql"
 	"goapi/database"
-	"goapi/resolvables/days"
-	"goapi/resolvables/intensity-zones"
-	"goapi/resolvables/plans"
-	"goapi/resolvables/weeks"
 	workout_intensities "goapi/resolvables/workout-intensities"
 	"goapi/resolvables/workouts"
 )
 
 func InitSchema(
-	resolvableIntensityZones intensityzones.Resolvable,
 	resolvableWorkout workouts.Resolvable,
-	resolvableDay days.Resolvable,
-	resolvableWeek weeks.Resolvable,
-	resolvablePlan plans.Resolvable,
 	resolvableWorkoutIntensities workout_intensities.Resolvable,
 	dbClient database.Client,
 ) (graphql.Schema, error) {
 	workoutType := workoutType(resolvableWorkoutIntensities)
-	dayType := dayType(resolvableWorkout, workoutType)
-	weekType := weekType(resolvableDay, dayType)
-	planType := planType(resolvableWeek, weekType)
 	recordType := recordType()
 	profileType := profileType(dbClient, recordType)
 	workoutV2Type := workoutV2Type(dbClient, profileType)
+	dayType := dayType(dbClient, workoutV2Type)
+	weekType := weekType(dbClient, dayType)
+	planType := planType(dbClient, weekType, profileType)
+	activityType := activityType(dbClient, workoutV2Type, dayType)
+	addRecordedActivityFields(dbClient, activityType)
+	addIntensityPaceField(dbClient)
+	profileType.AddFieldConfig("activities", profileActivitiesField(dbClient, activityConnectionType(activityType)))
+	profileType.AddFieldConfig("calendarPath", calendarPathField(dbClient))
+	planScheduleType := planScheduleType(dbClient, planType)
+	profileType.AddFieldConfig("schedules", profileSchedulesField(dbClient, planScheduleType))
+	profileType.AddFieldConfig("calendar", profileCalendarField(dbClient,
+		calendarDayType(dbClient, planScheduleType, weekType, dayType, workoutV2Type)))
+	adherenceType := adherenceType(dayComplianceType(dbClient, dayType, activityType))
+	planType.AddFieldConfig("adherence", planAdherenceField(dbClient, adherenceType))
+	profileType.AddFieldConfig("adherence", profileAdherenceField(dbClient, adherenceType))
+	profileType.AddFieldConfig("loadProgression", profileLoadProgressionField(dbClient))
 
 	rootQuery := graphql.NewObject(
 		graphql.ObjectConfig{
 			Name: "Query",
 			Fields: graphql.Fields{
-				"intensityZones": intensityZonesField(resolvableIntensityZones),
+				"intensityZones": intensityZonesField(dbClient),
 				"workouts":       workoutsField(resolvableWorkout, workoutType),
 				"workout":        workoutField(resolvableWorkout, workoutType),
-				"plans":          plansField(resolvablePlan, planType),
-				"plan":           planField(resolvablePlan, planType),
+				"plans":          plansField(dbClient, planType),
+				"plan":           planField(dbClient, planType),
 				"profiles":       profilesField(dbClient, profileType),
 				"profile":        profileField(dbClient, profileType),
 				"me":             meField(profileType),
 				"workoutV2s":     workoutV2sField(dbClient, workoutV2Type),
 				"workoutV2":      workoutV2Field(dbClient, workoutV2Type),
+				"vdotFromRace":   vdotFromRaceField(),
+				"paces":          pacesField(),
 			},
 		})
 
 	rootMutation := graphql.NewObject(graphql.ObjectConfig{
 		Name: "RootMutation",
 		Fields: graphql.Fields{
-			"createWorkout": createWorkoutV2Mutation(dbClient, workoutV2Type),
-			"addWorkoutPart": addWorkoutPartMutation(dbClient, workoutV2Type),
-			},
+			"createWorkout":         createWorkoutV2Mutation(dbClient, workoutV2Type),
+			"addWorkoutPart":        addWorkoutPartMutation(dbClient, workoutV2Type),
+			"createWorkoutFromText": createWorkoutFromTextMutation(dbClient, workoutV2Type),
+			"updateWorkout":         updateWorkoutMutation(dbClient, workoutV2Type),
+			"deleteWorkout":         deleteWorkoutMutation(dbClient),
+			"updateWorkoutPart":     updateWorkoutPartMutation(dbClient, workoutV2Type),
+			"removeWorkoutPart":     removeWorkoutPartMutation(dbClient, workoutV2Type),
+			"reorderWorkoutParts":   reorderWorkoutPartsMutation(dbClient, workoutV2Type),
+			"addRecord":             addRecordMutation(dbClient, recordType),
+			"deleteRecord":          deleteRecordMutation(dbClient),
+			"createPlan":            createPlanMutation(dbClient, planType),
+			"updatePlan":            updatePlanMutation(dbClient, planType),
+			"deletePlan":            deletePlanMutation(dbClient),
+			"addWeek":               addWeekMutation(dbClient, planType),
+			"moveWeek":              moveWeekMutation(dbClient, planType),
+			"removeWeek":            removeWeekMutation(dbClient, planType),
+			"setDayWorkouts":        setDayWorkoutsMutation(dbClient, planType),
+			"updateProfile":         updateProfileMutation(dbClient, profileType),
+			"logActivity":           logActivityMutation(dbClient, activityType),
+			"updateActivity":        updateActivityMutation(dbClient, activityType),
+			"deleteActivity":        deleteActivityMutation(dbClient),
+			"createIntensity":       createIntensityMutation(dbClient),
+			"updateIntensity":       updateIntensityMutation(dbClient),
+			"deleteIntensity":       deleteIntensityMutation(dbClient),
+			"reorderIntensities":    reorderIntensitiesMutation(dbClient),
+			"resetCalendarToken":    resetCalendarTokenMutation(dbClient),
+			"assignPlan":            assignPlanMutation(dbClient, planScheduleType),
+			"shiftSchedule":         shiftScheduleMutation(dbClient, planScheduleType),
+			"skipWeek":              skipWeekMutation(dbClient, planScheduleType),
+			"unassignPlan":          unassignPlanMutation(dbClient),
+		},
 	})
 
 	return graphql.NewSchema(
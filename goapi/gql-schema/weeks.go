@@ -2,12 +2,11 @@ package gqlschema
 
 import (
 	"github.com/graphql-go/graphql"
-	"goapi/gql-common"
-	"goapi/resolvables/days"
-	"goapi/resolvables/weeks"
+	"goapi/database"
+	"goapi/models"
 )
 
-func weekFields(resolvableDays days.Resolvable, dayType *graphql.Object) graphql.Fields {
+func weekFields(dbClient database.Client, dayType *graphql.Object) graphql.Fields {
 	return graphql.Fields{
 		"id": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.String),
@@ -18,48 +17,30 @@ func weekFields(resolvableDays days.Resolvable, dayType *graphql.Object) graphql
 		"days": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(dayType))),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				return resolvableDays.GetByParentId(p.Context, p.Source.(weeks.Week).Id)
+				return dbClient.GetDaysForWeek(p.Context, p.Source.(models.Week).Id)
 			},
 		},
 		"distance": &graphql.Field{
-			Type: graphql.NewNonNull(graphql.Int),
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The distance in meters, with parts measured in seconds estimated at the viewer's training paces",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				week := p.Source.(models.Week)
+				days, err := dbClient.GetDaysForWeek(p.Context, week.Id)
+				if err != nil {
+					return nil, err
+				}
+				return daysDistance(p, dbClient, week.PlanId, days)
+			},
 		},
+		"load": weekLoadField(dbClient),
 	}
 }
 
-func weekType(resolvableDays days.Resolvable, dayType *graphql.Object) *graphql.Object {
+func weekType(dbClient database.Client, dayType *graphql.Object) *graphql.Object {
 	return graphql.NewObject(
 		graphql.ObjectConfig{
 			Name:   "Week",
-			Fields: weekFields(resolvableDays, dayType),
+			Fields: weekFields(dbClient, dayType),
 		},
 	)
 }
-
-func weeksField(resolvableWeek weeks.Resolvable, weekType *graphql.Object) *graphql.Field {
-	return &graphql.Field{
-		Type: graphql.NewList(graphql.NewNonNull(weekType)),
-		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			return resolvableWeek.GetAll(p.Context)
-		},
-	}
-}
-
-func weekField(resolvableWeek weeks.Resolvable, weekType *graphql.Object) *graphql.Field {
-	return &graphql.Field{
-		Type: weekType,
-		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			id, err := gqlcommon.GetId(p)
-			if err != nil {
-				return nil, err
-			}
-			return resolvableWeek.Get(p.Context, id)
-		},
-		Args: map[string]*graphql.ArgumentConfig{
-			"id": {
-				Type:        graphql.NewNonNull(graphql.String),
-				Description: "The id of the week",
-			},
-		},
-	}
-}
@@ -0,0 +1,305 @@
+package gqlschema
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/load"
+	"goapi/models"
+	"math"
+)
+
+var (
+	metric = graphql.NewEnum(graphql.EnumConfig{
+		Name: "MetricV2",
+		Values: graphql.EnumValueConfigMap{
+			"METER": &graphql.EnumValueConfig{
+				Value: "meter",
+			},
+			"SECOND": &graphql.EnumValueConfig{
+				Value: "second",
+			},
+		},
+	})
+
+	partType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "WorkoutPart",
+			Description: "A part of a workout. Repeat blocks have a repeat count and child parts instead of an intensity.",
+			Fields: graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(estimatedPart).part.Id, nil
+					},
+				},
+				"order": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(estimatedPart).part.Order, nil
+					},
+				},
+				"distance": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(estimatedPart).part.Distance, nil
+					},
+				},
+				"metric": &graphql.Field{
+					Type: metric,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						part := p.Source.(estimatedPart).part
+						if part.IsRepeat() {
+							return nil, nil
+						}
+						return part.Metric, nil
+					},
+				},
+				"intensity": &graphql.Field{
+					Type: intensityType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						part := p.Source.(estimatedPart).part
+						if part.IsRepeat() {
+							return nil, nil
+						}
+						return part.Intensity, nil
+					},
+				},
+				"repeat": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						part := p.Source.(estimatedPart).part
+						if !part.IsRepeat() {
+							return nil, nil
+						}
+						return part.Repeat, nil
+					},
+				},
+				"estimatedSeconds": &graphql.Field{
+					Type:        graphql.Int,
+					Description: "The estimated duration of the part, including all repetitions, at the viewer's training paces",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(estimatedPart)
+						estimate, ok := source.estimator.Part(source.part)
+						if !ok {
+							return nil, nil
+						}
+						return int(math.Round(estimate.Seconds)), nil
+					},
+				},
+				"estimatedMeters": &graphql.Field{
+					Type:        graphql.Int,
+					Description: "The estimated distance of the part, including all repetitions, at the viewer's training paces",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(estimatedPart)
+						estimate, ok := source.estimator.Part(source.part)
+						if !ok {
+							return nil, nil
+						}
+						return int(math.Round(estimate.Meters)), nil
+					},
+				},
+				"load": &graphql.Field{
+					Type:        graphql.Float,
+					Description: "The estimated minutes of the part weighted by the coefficient of the intensity",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(estimatedPart)
+						value, ok := load.Part(source.estimator, source.part)
+						if !ok {
+							return nil, nil
+						}
+						return value, nil
+					},
+				},
+				"targetPace": &graphql.Field{
+					Type:        paceType,
+					Description: "The viewer's training pace for the intensity of the part. Empty for repeat blocks.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						source := p.Source.(estimatedPart)
+						if source.part.IsRepeat() {
+							return nil, nil
+						}
+						pace, ok := source.estimator.TargetPace(source.part.Intensity)
+						if !ok {
+							return nil, nil
+						}
+						return pace, nil
+					},
+				},
+			}})
+
+	stepType = graphql.NewObject(
+		graphql.ObjectConfig{
+			Name:        "WorkoutStep",
+			Description: "A plain part of a workout, as it is run after the repeat blocks are expanded",
+			Fields: graphql.Fields{
+				"order": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+				},
+				"distance": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+				},
+				"metric": &graphql.Field{
+					Type: graphql.NewNonNull(metric),
+				},
+				"intensity": &graphql.Field{
+					Type: graphql.NewNonNull(intensityType),
+				},
+			}})
+
+	partInputType = graphql.NewInputObject(
+		graphql.InputObjectConfig{
+			Name:        "WorkoutPartInput",
+			Description: "A part of a workout. Give either an intensity, or a repeat count with child parts.",
+			Fields: graphql.InputObjectConfigFieldMap{
+				"distance": &graphql.InputObjectFieldConfig{
+					Type: graphql.Int,
+				},
+				"metric": &graphql.InputObjectFieldConfig{
+					Type: metric,
+				},
+				"intensityId": &graphql.InputObjectFieldConfig{
+					Type: graphql.String,
+				},
+				"repeat": &graphql.InputObjectFieldConfig{
+					Type: graphql.Int,
+				},
+			},
+		})
+)
+
+// The recursive fields are added after the types are created, as a type can not refer to itself while it is initialized.
+func init() {
+	partInputType.AddFieldConfig("parts", &graphql.InputObjectFieldConfig{
+		Type: graphql.NewList(graphql.NewNonNull(partInputType)),
+	})
+	partType.AddFieldConfig("parts", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(partType))),
+		Description: "The parts of a repeat block. Empty for plain parts.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			source := p.Source.(estimatedPart)
+			return estimatedParts(source.part.Parts, source.estimator), nil
+		},
+	})
+}
+
+// estimatedPart is a workout part together with the estimator for the training paces of the viewer.
+type estimatedPart struct {
+	part      models.WorkoutPart
+	estimator estimates.Estimator
+}
+
+func estimatedParts(parts []models.WorkoutPart, estimator estimates.Estimator) []estimatedPart {
+	estimated := make([]estimatedPart, 0, len(parts))
+	for _, part := range parts {
+		estimated = append(estimated, estimatedPart{part: part, estimator: estimator})
+	}
+	return estimated
+}
+
+// workoutPartsFromArgument maps a list of WorkoutPartInput values to workout parts, ordered as they are listed,
+// and checks the limits of repeat blocks and that the logged in user owns the intensities.
+func workoutPartsFromArgument(p graphql.ResolveParams, dbClient database.Client, value interface{}) ([]models.WorkoutPart, error) {
+	parts, err := partsFromArgument(value)
+	if err != nil {
+		return nil, err
+	}
+	err = models.CheckWorkoutParts(parts)
+	if err != nil {
+		return nil, err
+	}
+	err = ensureOwnedIntensities(p, dbClient, parts, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// ensureOwnedIntensities checks the intensity of every part once, including the parts of repeat blocks.
+func ensureOwnedIntensities(p graphql.ResolveParams, dbClient database.Client, parts []models.WorkoutPart, checked map[string]bool) error {
+	for _, part := range parts {
+		if part.IsRepeat() {
+			err := ensureOwnedIntensities(p, dbClient, part.Parts, checked)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if checked[part.Intensity.Id] {
+			continue
+		}
+		_, err := ownedIntensity(p, dbClient, part.Intensity.Id)
+		if err != nil {
+			return err
+		}
+		checked[part.Intensity.Id] = true
+	}
+	return nil
+}
+
+func partsFromArgument(value interface{}) ([]models.WorkoutPart, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("parts must be a list")
+	}
+
+	parts := make([]models.WorkoutPart, 0, len(values))
+	for order, value := range values {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("parts must be a list of objects")
+		}
+
+		part := models.WorkoutPart{Order: order}
+		if repeat, ok := fields["repeat"].(int); ok {
+			if repeat < 1 || repeat > models.MaxRepeat {
+				return nil, models.ErrRepeatTooLarge
+			}
+			children, err := partsFromArgument(fields["parts"])
+			if err != nil || len(children) == 0 {
+				return nil, errors.New("a repeat block must have at least one part")
+			}
+			part.Repeat = repeat
+			part.Parts = children
+		} else {
+			intensityId, ok := fields["intensityId"].(string)
+			if !ok {
+				return nil, errors.New("a part must have either an intensityId or a repeat count")
+			}
+			part.Intensity = models.Intensity{Id: intensityId}
+			part.Distance, _ = fields["distance"].(int)
+			part.Metric, ok = fields["metric"].(string)
+			if !ok {
+				part.Metric = "meter"
+			}
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// findWorkoutPart searches the tree of parts for the part with the given id.
+func findWorkoutPart(parts []models.WorkoutPart, id string) *models.WorkoutPart {
+	for i := range parts {
+		if parts[i].Id == id {
+			return &parts[i]
+		}
+		if found := findWorkoutPart(parts[i].Parts, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// siblingWorkoutParts returns the top level parts, or the parts of the repeat block given by parentId.
+func siblingWorkoutParts(parts []models.WorkoutPart, parentId string) ([]models.WorkoutPart, error) {
+	if parentId == "" {
+		return parts, nil
+	}
+	parent := findWorkoutPart(parts, parentId)
+	if parent == nil || !parent.IsRepeat() {
+		return nil, errors.New("the parent must be a repeat block in the same workout")
+	}
+	return parent.Parts, nil
+}
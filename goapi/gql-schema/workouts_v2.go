@@ -5,41 +5,11 @@ import (
 	"github.com/graphql-go/graphql"
 	"goapi/appcontext"
 	"goapi/database"
+	"goapi/estimates"
 	"goapi/gql-common"
 	"goapi/logger"
 	"goapi/models"
-)
-
-var (
-	metric = graphql.NewNonNull(graphql.NewEnum(graphql.EnumConfig{
-		Name: "MetricV2",
-		Values: graphql.EnumValueConfigMap{
-			"METER": &graphql.EnumValueConfig{
-				Value: "meter",
-			},
-			"SECOND": &graphql.EnumValueConfig{
-				Value: "second",
-			},
-		},
-	}))
-
-	partType = graphql.NewObject(
-		graphql.ObjectConfig{
-			Name: "WorkoutPart",
-			Fields: graphql.Fields{
-				"order": &graphql.Field{
-					Type: graphql.NewNonNull(graphql.Int),
-				},
-				"distance": &graphql.Field{
-					Type: graphql.NewNonNull(graphql.Int),
-				},
-				"metric": &graphql.Field{
-					Type: metric,
-				},
-				"intensity": &graphql.Field{
-					Type: graphql.NewNonNull(intensityType),
-				},
-			}})
+	"math"
 )
 
 func workoutV2Fields(dbClient database.Client, profileType *graphql.Object) graphql.Fields {
@@ -56,9 +26,64 @@ func workoutV2Fields(dbClient database.Client, profileType *graphql.Object) grap
 		"parts": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(partType))),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				return dbClient.GetWorkoutPartsForWorkout(p.Context, p.Source.(models.Workout).Id)
+				workout := p.Source.(models.Workout)
+				parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, workout.Id)
+				if err != nil {
+					return nil, err
+				}
+				estimator, err := viewerEstimator(p, dbClient, workout.CreatedBy)
+				if err != nil {
+					return nil, err
+				}
+				return estimatedParts(parts, estimator), nil
+			},
+		},
+		"estimatedSeconds": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "The estimated duration of the workout at the viewer's training paces",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				estimate, ok, err := estimateWorkout(p, dbClient, p.Source.(models.Workout))
+				if err != nil || !ok {
+					return nil, err
+				}
+				return int(math.Round(estimate.Seconds)), nil
+			},
+		},
+		"estimatedMeters": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "The estimated distance of the workout at the viewer's training paces",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				estimate, ok, err := estimateWorkout(p, dbClient, p.Source.(models.Workout))
+				if err != nil || !ok {
+					return nil, err
+				}
+				return int(math.Round(estimate.Meters)), nil
+			},
+		},
+		"load": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "The estimated minutes of the workout weighted by the coefficients of the intensities",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				workout := p.Source.(models.Workout)
+				estimator, err := viewerEstimator(p, dbClient, workout.CreatedBy)
+				if err != nil {
+					return nil, err
+				}
+				return nullableLoad(workoutLoad(p.Context, dbClient, estimator, workout))
 			},
 		},
+		"steps": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(stepType))),
+			Description: "The parts of the workout in the order they are run, with the repeat blocks expanded",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, p.Source.(models.Workout).Id)
+				if err != nil {
+					return nil, err
+				}
+				return models.FlattenWorkoutParts(parts), nil
+			},
+		},
+		"notation": workoutNotationField(dbClient),
 		"createdBy": &graphql.Field{
 			Type: graphql.NewNonNull(profileType),
 			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
@@ -77,6 +102,38 @@ func workoutV2Type(dbClient database.Client, profileType *graphql.Object) *graph
 	)
 }
 
+// viewerEstimator estimates workouts at the training paces of the logged in user, or of the given creator of
+// the workout or plan when nobody is logged in.
+func viewerEstimator(p graphql.ResolveParams, dbClient database.Client, creatorId string) (estimates.Estimator, error) {
+	profile, err := appcontext.Profile(p.Context)
+	if authenticated, _ := appcontext.UserAuthenticated(p.Context); !authenticated || err != nil {
+		profile, err = dbClient.GetProfile(p.Context, creatorId)
+		if err != nil {
+			return estimates.Estimator{}, err
+		}
+	}
+
+	value, err := currentVdot(p.Context, dbClient, profile, defaultVdotMonths)
+	if err != nil {
+		return estimates.Estimator{}, err
+	}
+	return estimates.New(value), nil
+}
+
+// estimateWorkout estimates all the parts of a workout at the viewer's training paces.
+func estimateWorkout(p graphql.ResolveParams, dbClient database.Client, workout models.Workout) (estimates.Estimate, bool, error) {
+	parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, workout.Id)
+	if err != nil {
+		return estimates.Estimate{}, false, err
+	}
+	estimator, err := viewerEstimator(p, dbClient, workout.CreatedBy)
+	if err != nil {
+		return estimates.Estimate{}, false, err
+	}
+	estimate, ok := estimator.Parts(parts)
+	return estimate, ok, nil
+}
+
 func workoutV2sField(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(workoutType))),
@@ -108,21 +165,14 @@ func workoutV2Field(dbClient database.Client, workoutType *graphql.Object) *grap
 func createWorkoutV2Mutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
 	name := "name"
 	description := "description"
+	parts := "parts"
 
 	return &graphql.Field{
 		Type: workoutType,
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			log := logger.FromContext(p.Context)
-
-			authenticated, err := appcontext.UserAuthenticated(p.Context)
-			if err != nil || !authenticated {
-				log.Error("The user must be logged in to use this query")
-				return nil, errors.New("the user must be logged in to use this query")
-			}
-			profile, err := appcontext.Profile(p.Context)
-			if err != nil || !authenticated {
-				log.Error("Profile expected to be on Context, but was not found.")
-				return nil, errors.New("unexpected error")
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
 			}
 
 			text, err := gqlcommon.GetStringArgument(p, name)
@@ -133,8 +183,15 @@ func createWorkoutV2Mutation(dbClient database.Client, workoutType *graphql.Obje
 			if err != nil {
 				description = ""
 			}
+			var workoutParts []models.WorkoutPart
+			if value, exist := p.Args[parts]; exist {
+				workoutParts, err = workoutPartsFromArgument(p, dbClient, value)
+				if err != nil {
+					return nil, err
+				}
+			}
 
-			return dbClient.CreateWorkout(p.Context, text, description, profile.Id)
+			return dbClient.CreateWorkout(p.Context, text, description, workoutParts, profile.Id)
 		},
 		Args: graphql.FieldConfigArgument{
 			name: &graphql.ArgumentConfig{
@@ -143,37 +200,52 @@ func createWorkoutV2Mutation(dbClient database.Client, workoutType *graphql.Obje
 			description: &graphql.ArgumentConfig{
 				Type: graphql.String,
 			},
+			parts: &graphql.ArgumentConfig{
+				Type: graphql.NewList(graphql.NewNonNull(partInputType)),
+			},
 		},
 	}
 }
 
 func addWorkoutPartMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
 	workoutId := "workoutId"
+	parentId := "parentId"
 	order := "order"
 	distance := "distance"
 	metric := "metric"
 	intensityId := "intensityId"
+	repeat := "repeat"
 
 	return &graphql.Field{
 		Type: workoutType,
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			log := logger.FromContext(p.Context)
-
-			authenticated, err := appcontext.UserAuthenticated(p.Context)
-			if err != nil || !authenticated {
-				log.Error("The user must be logged in to use this query")
-				return nil, errors.New("the user must be logged in to use this query")
-			}
-			profile, err := appcontext.Profile(p.Context)
-			if err != nil || !authenticated {
-				log.Error("Profile expected to be on Context, but was not found.")
-				return nil, errors.New("unexpected error")
+			profile, err := authenticatedProfile(p)
+			if err != nil {
+				return nil, err
 			}
 
 			workoutId, err := gqlcommon.GetStringArgument(p, workoutId)
 			if err != nil {
 				return nil, err
 			}
+			_, err = ownedWorkout(p, dbClient, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			parentId, err := gqlcommon.GetStringArgument(p, parentId)
+			if err != nil {
+				parentId = ""
+			}
+			if parentId != "" {
+				parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, workoutId)
+				if err != nil {
+					return nil, err
+				}
+				_, err = siblingWorkoutParts(parts, parentId)
+				if err != nil {
+					return nil, err
+				}
+			}
 			order, err := gqlcommon.GetIntArgument(p, order)
 			if err != nil {
 				return nil, err
@@ -182,33 +254,348 @@ func addWorkoutPartMutation(dbClient database.Client, workoutType *graphql.Objec
 			if err != nil {
 				distance = 0
 			}
+			repeat, err := gqlcommon.GetIntArgument(p, repeat)
+			if err != nil {
+				repeat = 0
+			}
+			if repeat > models.MaxRepeat {
+				return nil, models.ErrRepeatTooLarge
+			}
+			if repeat > 0 {
+				return dbClient.AddWorkoutPart(p.Context, workoutId, parentId, order, 0, "", "", repeat, profile.Id)
+			}
+
 			metric, err := gqlcommon.GetStringArgument(p, metric)
 			if err != nil {
 				metric = "meter"
 			}
 			intensityId, err := gqlcommon.GetStringArgument(p, intensityId)
+			if err != nil {
+				return nil, errors.New("a part must have either an intensityId or a repeat count")
+			}
+			_, err = ownedIntensity(p, dbClient, intensityId)
 			if err != nil {
 				return nil, err
 			}
 
-			return dbClient.AddWorkoutPart(p.Context, workoutId, order, distance, metric, intensityId, profile.Id)
+			return dbClient.AddWorkoutPart(p.Context, workoutId, parentId, order, distance, metric, intensityId, 0, profile.Id)
 		},
 		Args: graphql.FieldConfigArgument{
 			workoutId: &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.String),
 			},
+			parentId: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The repeat block to add the part to. The part is added to the top level if omitted.",
+			},
 			order: &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.Int),
 			},
 			distance: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			metric: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			intensityId: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			repeat: &graphql.ArgumentConfig{
+				Type:        graphql.Int,
+				Description: "Creates an empty repeat block, which is run this many times",
+			},
+		},
+	}
+}
+
+// ownedWorkout fetches a workout and verifies that it was created by the logged in user.
+func ownedWorkout(p graphql.ResolveParams, dbClient database.Client, workoutId string) (models.Workout, error) {
+	profile, err := authenticatedProfile(p)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	workout, err := dbClient.GetWorkout(p.Context, workoutId)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	if workout.CreatedBy != profile.Id {
+		logger.FromContext(p.Context).Warn("The user tried to change a workout created by someone else")
+		return models.Workout{}, errNotOwner
+	}
+
+	return workout, nil
+}
+
+func updateWorkoutMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
+	name := "name"
+	description := "description"
+	parts := "parts"
+
+	return &graphql.Field{
+		Type: workoutType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			workout, err := ownedWorkout(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := gqlcommon.GetStringArgument(p, name)
+			if err != nil {
+				name = workout.Name
+			}
+			description, err := gqlcommon.GetStringArgument(p, description)
+			if err != nil {
+				description = workout.Description
+			}
+
+			value, replaceParts := p.Args[parts]
+			var workoutParts []models.WorkoutPart
+			if replaceParts {
+				workoutParts, err = workoutPartsFromArgument(p, dbClient, value)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if !replaceParts {
+				return dbClient.UpdateWorkout(p.Context, id, name, description)
+			}
+			return dbClient.UpdateWorkoutWithParts(p.Context, id, name, description, workoutParts, workout.CreatedBy)
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			name: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			description: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			parts: &graphql.ArgumentConfig{
+				Type:        graphql.NewList(graphql.NewNonNull(partInputType)),
+				Description: "Replaces all the parts of the workout when given",
+			},
+		},
+	}
+}
+
+func deleteWorkoutMutation(dbClient database.Client) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			id, err := gqlcommon.GetId(p)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWorkout(p, dbClient, id)
+			if err != nil {
+				return nil, err
+			}
+
+			err = dbClient.DeleteWorkout(p.Context, id)
+			if err != nil {
+				return nil, err
+			}
+			return true, nil
+		},
+		Args: graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	}
+}
+
+func updateWorkoutPartMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
+	workoutId := "workoutId"
+	parentId := "parentId"
+	order := "order"
+	distance := "distance"
+	metric := "metric"
+	intensityId := "intensityId"
+	repeat := "repeat"
+
+	return &graphql.Field{
+		Type: workoutType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			workoutId, err := gqlcommon.GetStringArgument(p, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWorkout(p, dbClient, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			parentId, err := gqlcommon.GetStringArgument(p, parentId)
+			if err != nil {
+				parentId = ""
+			}
+			order, err := gqlcommon.GetIntArgument(p, order)
+			if err != nil {
+				return nil, err
+			}
+
+			parts, err := dbClient.GetWorkoutPartsForWorkout(p.Context, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			siblings, err := siblingWorkoutParts(parts, parentId)
+			if err != nil {
+				return nil, err
+			}
+			var existing *models.WorkoutPart
+			for i := range siblings {
+				if siblings[i].Order == order {
+					existing = &siblings[i]
+				}
+			}
+			if existing == nil {
+				return nil, errors.New("the workout has no part with that order")
+			}
+
+			if existing.IsRepeat() {
+				repeat, ok := p.Args[repeat].(int)
+				if !ok {
+					repeat = existing.Repeat
+				}
+				if repeat < 1 || repeat > models.MaxRepeat {
+					return nil, models.ErrRepeatTooLarge
+				}
+				return dbClient.UpdateWorkoutPart(p.Context, workoutId, parentId, order, 0, "", "", repeat)
+			}
+
+			distance, ok := p.Args[distance].(int)
+			if !ok {
+				distance = existing.Distance
+			}
+			metric, err := gqlcommon.GetStringArgument(p, metric)
+			if err != nil {
+				metric = existing.Metric
+			}
+			intensityId, err := gqlcommon.GetStringArgument(p, intensityId)
+			if err != nil {
+				intensityId = existing.Intensity.Id
+			} else if _, err := ownedIntensity(p, dbClient, intensityId); err != nil {
+				return nil, err
+			}
+
+			return dbClient.UpdateWorkoutPart(p.Context, workoutId, parentId, order, distance, metric, intensityId, 0)
+		},
+		Args: graphql.FieldConfigArgument{
+			workoutId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			parentId: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The repeat block containing the part. The part is looked up at the top level if omitted.",
+			},
+			order: &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.Int),
 			},
+			distance: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
 			metric: &graphql.ArgumentConfig{
 				Type: graphql.String,
 			},
 			intensityId: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			repeat: &graphql.ArgumentConfig{
+				Type:        graphql.Int,
+				Description: "The new repeat count, if the part is a repeat block",
+			},
+		},
+	}
+}
+
+func removeWorkoutPartMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
+	workoutId := "workoutId"
+	parentId := "parentId"
+	order := "order"
+
+	return &graphql.Field{
+		Type: workoutType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			workoutId, err := gqlcommon.GetStringArgument(p, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWorkout(p, dbClient, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			parentId, err := gqlcommon.GetStringArgument(p, parentId)
+			if err != nil {
+				parentId = ""
+			}
+			order, err := gqlcommon.GetIntArgument(p, order)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.RemoveWorkoutPart(p.Context, workoutId, parentId, order)
+		},
+		Args: graphql.FieldConfigArgument{
+			workoutId: &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.String),
 			},
+			parentId: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The repeat block containing the parts. The top level parts are used if omitted.",
+			},
+			order: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
 		},
 	}
-}
\ No newline at end of file
+}
+
+func reorderWorkoutPartsMutation(dbClient database.Client, workoutType *graphql.Object) *graphql.Field {
+	workoutId := "workoutId"
+	parentId := "parentId"
+	orders := "orders"
+
+	return &graphql.Field{
+		Type: workoutType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			workoutId, err := gqlcommon.GetStringArgument(p, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			_, err = ownedWorkout(p, dbClient, workoutId)
+			if err != nil {
+				return nil, err
+			}
+			parentId, err := gqlcommon.GetStringArgument(p, parentId)
+			if err != nil {
+				parentId = ""
+			}
+			orders, err := gqlcommon.GetIntListArgument(p, orders)
+			if err != nil {
+				return nil, err
+			}
+
+			return dbClient.ReorderWorkoutParts(p.Context, workoutId, parentId, orders)
+		},
+		Args: graphql.FieldConfigArgument{
+			workoutId: &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			parentId: &graphql.ArgumentConfig{
+				Type:        graphql.String,
+				Description: "The repeat block containing the parts. The top level parts are used if omitted.",
+			},
+			orders: &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int))),
+				Description: "The current orders of all the parts, listed in their new sequence",
+			},
+		},
+	}
+}
@@ -0,0 +1,117 @@
+// Package ical writes calendars in the iCalendar format of RFC 5545, which Google, Apple and Outlook calendars
+// subscribe to. Only all-day events are supported.
+package ical
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	productId = "-//S33//Treningsplan//EN"
+
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+
+	// maxLineOctets is the longest a content line can be before it has to be folded.
+	maxLineOctets = 75
+)
+
+// Calendar is a calendar of all-day events.
+type Calendar struct {
+	Name     string
+	TimeZone string
+	Events   []Event
+}
+
+// Event is an all-day event. The UID identifies the event across updates, and the sequence grows with every
+// change to it.
+type Event struct {
+	UID         string
+	Sequence    int
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// ContentHash identifies what the event shows, so a change to it can be told apart from an unchanged event by
+// comparing the hashes.
+func (e Event) ContentHash() string {
+	hash := sha256.New()
+	for _, field := range []string{e.Date.Format(dateLayout), e.Summary, e.Description} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Encode writes the calendar. The time stamp is when the calendar was written.
+func (c Calendar) Encode(stamp time.Time) []byte {
+	var buffer bytes.Buffer
+	w := writer{buffer: &buffer}
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", productId)
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		w.line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.TimeZone != "" {
+		w.line("X-WR-TIMEZONE", escape(c.TimeZone))
+	}
+
+	for _, event := range c.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", escape(event.UID))
+		w.line("SEQUENCE", strconv.Itoa(event.Sequence))
+		w.line("DTSTAMP", stamp.UTC().Format(dateTimeLayout))
+		w.line("DTSTART;VALUE=DATE", event.Date.Format(dateLayout))
+		w.line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateLayout))
+		w.line("SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			w.line("DESCRIPTION", escape(event.Description))
+		}
+		w.line("TRANSP", "TRANSPARENT")
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return buffer.Bytes()
+}
+
+type writer struct {
+	buffer *bytes.Buffer
+}
+
+// line writes a content line, folding it into lines of at most 75 octets without splitting characters.
+func (w writer) line(name, value string) {
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buffer.WriteString(line[:cut])
+		w.buffer.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards their length.
+		limit = maxLineOctets - 1
+	}
+	w.buffer.WriteString(line)
+	w.buffer.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes the characters that have a meaning in text values.
+func escape(value string) string {
+	return escaper.Replace(value)
+}
@@ -0,0 +1,120 @@
+// Package load scores training load. The load of a part of a workout is its estimated duration in minutes
+// weighted by the coefficient of its intensity, so an hour easy (0.2) scores 12 and an hour of intervals (1.0)
+// scores 60.
+//
+// Weekly loads are judged with the acute:chronic workload ratio, the load of a week divided by the average
+// weekly load of the last four weeks, and with Foster's monotony and strain. Monotony is the average daily
+// load of a week divided by its standard deviation, and strain is the weekly load multiplied by the monotony.
+// A week with the same load every day has no deviation to divide by, and its monotony and strain are unknown
+// rather than infinite.
+package load
+
+import (
+	"goapi/estimates"
+	"goapi/models"
+	"math"
+)
+
+const (
+	daysPerWeek = 7
+
+	// ChronicWeeks is the number of weeks, including the current one, the chronic load is averaged over.
+	ChronicWeeks = 4
+)
+
+// Part is the load of a part of a workout, including all repetitions of a repeat block. The second return
+// value is false when the duration of the part can not be estimated.
+func Part(estimator estimates.Estimator, part models.WorkoutPart) (float64, bool) {
+	if part.IsRepeat() {
+		load, ok := Parts(estimator, part.Parts)
+		return load * float64(part.Repeat), ok
+	}
+
+	estimate, ok := estimator.Part(part)
+	if !ok {
+		return 0, false
+	}
+	return estimate.Seconds / 60 * part.Intensity.Coefficient, true
+}
+
+// Parts sums up the load of a list of parts, like all the parts of a workout.
+func Parts(estimator estimates.Estimator, parts []models.WorkoutPart) (float64, bool) {
+	total := 0.0
+	for _, part := range parts {
+		load, ok := Part(estimator, part)
+		if !ok {
+			return 0, false
+		}
+		total += load
+	}
+	return total, true
+}
+
+// Week is the load of a week and how it compares with the weeks before.
+type Week struct {
+	Load float64
+	// Monotony and Strain are nil for weeks with load where every day has the same load.
+	Monotony *float64
+	Strain   *float64
+	// AcuteChronicRatio is only set when there are enough weeks before to average the chronic load over.
+	AcuteChronicRatio *float64
+}
+
+// Weeks summarizes daily loads week by week. The first seven loads are the first week, and so on. A last
+// week with fewer than seven days is treated as if the missing days were rest days.
+func Weeks(daily []float64) []Week {
+	weekCount := (len(daily) + daysPerWeek - 1) / daysPerWeek
+	weeks := make([]Week, 0, weekCount)
+	for i := 0; i < weekCount; i++ {
+		days := make([]float64, daysPerWeek)
+		copy(days, daily[i*daysPerWeek:])
+
+		week := Week{Load: sum(days)}
+		if value, ok := monotony(days); ok {
+			strain := week.Load * value
+			week.Monotony, week.Strain = &value, &strain
+		}
+		weeks = append(weeks, week)
+
+		if i+1 >= ChronicWeeks {
+			chronic := 0.0
+			for _, previous := range weeks[i+1-ChronicWeeks:] {
+				chronic += previous.Load
+			}
+			chronic /= ChronicWeeks
+			if chronic > 0 {
+				ratio := week.Load / chronic
+				weeks[i].AcuteChronicRatio = &ratio
+			}
+		}
+	}
+	return weeks
+}
+
+// monotony is the average daily load divided by the standard deviation. The second return value is false when
+// every day has the same load, which has no deviation to divide by. Weeks of rest have a monotony of 0.
+func monotony(days []float64) (float64, bool) {
+	mean := sum(days) / float64(len(days))
+
+	variance := 0.0
+	for _, day := range days {
+		variance += (day - mean) * (day - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(days)))
+	switch {
+	case mean == 0:
+		return 0, true
+	case deviation <= mean*1e-9:
+		// Equal loads can leave a rounding error instead of a deviation of exactly 0.
+		return 0, false
+	}
+	return mean / deviation, true
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
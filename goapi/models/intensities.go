@@ -0,0 +1,6 @@
+package models
+
+// IsSet tells if a target range is given. Ranges that are not set have a max of 0.
+func (r Range) IsSet() bool {
+	return r.Max > 0
+}
@@ -1,10 +1,30 @@
 package models
 
+import "time"
+
 type Intensity struct {
 	Id          string
 	Name        string
 	Description string
 	Coefficient float64
+	Order       int
+	CreatedBy   string
+	Targets     IntensityTargets
+}
+
+// IntensityTargets are percentages of max heart rate, heart rate reserve, VDOT and threshold power, and RPE
+// values on the 1-10 scale.
+type IntensityTargets struct {
+	HeartRateMax     Range
+	HeartRateReserve Range
+	Vdot             Range
+	Rpe              Range
+	Power            Range
+}
+
+type Range struct {
+	Min float64
+	Max float64
 }
 
 type Workout struct {
@@ -15,21 +35,110 @@ type Workout struct {
 }
 
 type WorkoutPart struct {
+	Id        string
 	Order     int
 	Distance  int
 	Metric    string
 	Intensity Intensity
+	Repeat    int
+	Parts     []WorkoutPart
 }
 
 type Profile struct {
-	Id        string
-	FirstName string
-	LastName  string
-	Vdot      int
+	Id               string
+	FirstName        string
+	LastName         string
+	Vdot             int
+	MaxHeartRate     int
+	RestingHeartRate int
+	ThresholdPower   int
 }
 
 type Record struct {
-	Id string
-	Race string
-	Duration string
+	Id        string
+	ProfileId string
+	Race      string
+	Distance  int
+	Duration  int
+	Date      time.Time
+}
+
+type Plan struct {
+	Id          string
+	Name        string
+	Description string
+	CreatedBy   string
+	// Revision counts the changes to the plan.
+	Revision int
+}
+
+type Week struct {
+	Id     string
+	PlanId string
+	Order  int
+}
+
+type Day struct {
+	Id     string
+	WeekId string
+	Day    int
+}
+
+// PlanSchedule places a plan on the calendar of a profile. The plan either starts on the start date, or ends on
+// the race date; the other date is zero.
+type PlanSchedule struct {
+	Id        string
+	ProfileId string
+	PlanId    string
+	StartDate time.Time
+	RaceDate  time.Time
+	TimeZone  string
+	// SkippedWeeks are the orders of the plan weeks that are left out. The weeks are stored by id, so they keep
+	// being skipped when the weeks of the plan are moved.
+	SkippedWeeks []int
+	// Revision counts the changes to the schedule.
+	Revision int
+}
+
+type Activity struct {
+	Id               string
+	ProfileId        string
+	StartTime        time.Time
+	Duration         int
+	MovingTime       int
+	Distance         int
+	ElevationGain    int
+	AverageHeartRate int
+	MaxHeartRate     int
+	Rpe              int
+	Notes            string
+	WorkoutId        string
+	DayId            string
+	Source           string
+}
+
+type Lap struct {
+	Order            int
+	StartTime        time.Time
+	Duration         float64
+	Distance         float64
+	AverageHeartRate int
+	MaxHeartRate     int
+}
+
+// Sample is a point of a recorded activity. Distance is the distance covered since the start of the activity.
+// Segment numbers the parts of a recording that were recorded in one go, like the segments of a GPX track;
+// no distance is covered between segments.
+type Sample struct {
+	Time         time.Time
+	HasPosition  bool
+	Latitude     float64
+	Longitude    float64
+	HasElevation bool
+	Elevation    float64
+	Distance     float64
+	HeartRate    int
+	Cadence      int
+	Power        int
+	Segment      int
 }
@@ -0,0 +1,82 @@
+package models
+
+import "fmt"
+
+const (
+	// MaxRepeat is the largest number of times a repeat block can be run.
+	MaxRepeat = 100
+	// MaxRepeatDepth is how many repeat blocks can be nested inside each other.
+	MaxRepeatDepth = 3
+	// MaxWorkoutSteps is the largest number of plain parts a workout can have when its repeat blocks are expanded.
+	MaxWorkoutSteps = 1000
+)
+
+var (
+	ErrRepeatTooLarge = fmt.Errorf("repeat must be between 1 and %d", MaxRepeat)
+	ErrRepeatTooDeep  = fmt.Errorf("repeat blocks can be nested at most %d deep", MaxRepeatDepth)
+	ErrTooManySteps   = fmt.Errorf("a workout can have at most %d parts when its repeat blocks are expanded", MaxWorkoutSteps)
+)
+
+// IsRepeat tells if the part is a block of child parts, which are run Repeat times.
+func (part WorkoutPart) IsRepeat() bool {
+	return part.Repeat > 0
+}
+
+// CheckWorkoutParts verifies that the repeat blocks of a workout are within MaxRepeat and MaxRepeatDepth, and
+// that the workout expands to at most MaxWorkoutSteps plain parts.
+func CheckWorkoutParts(parts []WorkoutPart) error {
+	_, err := countSteps(parts, 0)
+	return err
+}
+
+func countSteps(parts []WorkoutPart, depth int) (int, error) {
+	count := 0
+	for _, part := range parts {
+		if !part.IsRepeat() {
+			count++
+		} else {
+			if part.Repeat > MaxRepeat {
+				return 0, ErrRepeatTooLarge
+			}
+			if depth >= MaxRepeatDepth {
+				return 0, ErrRepeatTooDeep
+			}
+			inner, err := countSteps(part.Parts, depth+1)
+			if err != nil {
+				return 0, err
+			}
+			count += inner * part.Repeat
+		}
+		if count > MaxWorkoutSteps {
+			return 0, ErrTooManySteps
+		}
+	}
+	return count, nil
+}
+
+// FlattenWorkoutParts expands the repeat blocks of a workout into the plain parts they consist of,
+// numbered in the order they are run. Workouts stored before the limits were enforced are cut off after
+// MaxWorkoutSteps parts.
+func FlattenWorkoutParts(parts []WorkoutPart) []WorkoutPart {
+	var steps []WorkoutPart
+	appendSteps(&steps, parts)
+	return steps
+}
+
+func appendSteps(steps *[]WorkoutPart, parts []WorkoutPart) {
+	for _, part := range parts {
+		if len(*steps) >= MaxWorkoutSteps {
+			return
+		}
+		if !part.IsRepeat() {
+			step := part
+			step.Order = len(*steps)
+			step.Parts = nil
+			*steps = append(*steps, step)
+			continue
+		}
+		for i := 0; i < part.Repeat && len(*steps) < MaxWorkoutSteps; i++ {
+			appendSteps(steps, part.Parts)
+		}
+	}
+}
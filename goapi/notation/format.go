@@ -0,0 +1,74 @@
+package notation
+
+import (
+	"fmt"
+	"goapi/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Format writes the parts of a workout in the notation. Intensities are written with their first letter
+// when no other of the given intensities starts with the same letter, and with their full name otherwise.
+func Format(parts []models.WorkoutPart, intensities []models.Intensity) string {
+	return formatSequence(parts, intensities, " + ")
+}
+
+func formatSequence(parts []models.WorkoutPart, intensities []models.Intensity, separator string) string {
+	formatted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		formatted = append(formatted, formatPart(part, intensities))
+	}
+	return strings.Join(formatted, separator)
+}
+
+func formatPart(part models.WorkoutPart, intensities []models.Intensity) string {
+	if part.IsRepeat() {
+		if len(part.Parts) == 1 && !part.Parts[0].IsRepeat() {
+			return fmt.Sprintf("%dx%s", part.Repeat, formatPart(part.Parts[0], intensities))
+		}
+		return fmt.Sprintf("%dx(%s)", part.Repeat, formatSequence(part.Parts, intensities, " / "))
+	}
+
+	return formatQuantity(part.Distance, part.Metric) + " " + abbreviate(part.Intensity, intensities)
+}
+
+func formatQuantity(amount int, metric string) string {
+	if metric == metricSecond {
+		switch {
+		case amount >= 3600 && amount%3600 == 0:
+			return strconv.Itoa(amount/3600) + "h"
+		case amount >= 60 && amount%60 == 0:
+			return strconv.Itoa(amount/60) + "min"
+		case amount > 60:
+			return fmt.Sprintf("%d:%02d", amount/60, amount%60)
+		default:
+			return strconv.Itoa(amount) + "s"
+		}
+	}
+
+	switch {
+	case amount >= 1000 && amount%1000 == 0:
+		return strconv.Itoa(amount/1000) + "km"
+	case amount >= 1000 && amount%100 == 0:
+		return strconv.FormatFloat(float64(amount)/1000, 'f', -1, 64) + "km"
+	default:
+		return strconv.Itoa(amount) + "m"
+	}
+}
+
+func abbreviate(intensity models.Intensity, intensities []models.Intensity) string {
+	name := []rune(intensity.Name)
+	if len(name) == 0 || !unicode.IsLetter(name[0]) {
+		return intensity.Name
+	}
+
+	first := unicode.ToLower(name[0])
+	for _, other := range intensities {
+		otherName := []rune(other.Name)
+		if other.Id != intensity.Id && len(otherName) > 0 && unicode.ToLower(otherName[0]) == first {
+			return intensity.Name
+		}
+	}
+	return string(unicode.ToUpper(name[0]))
+}
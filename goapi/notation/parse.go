@@ -0,0 +1,298 @@
+// Package notation reads and writes workouts in the text notation used by coaches,
+// like "10min E + 4x(4min I / 3min E) + 10min E".
+//
+// A workout is a list of parts separated by "+", "/" or ",". A plain part is a quantity,
+// a unit and an intensity, like "400m R" or "1:30 I". A repeat block is a count followed
+// by "x" and either a single part or a parenthesized list of parts, like "4x(4min I / 3min E)".
+// Repeat blocks are limited like all workouts, see models.CheckWorkoutParts.
+// Intensities are matched case-insensitively against the full name or a unique prefix of
+// the name, so "E" matches "Easy".
+package notation
+
+import (
+	"fmt"
+	"goapi/models"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	metricMeter  = "meter"
+	metricSecond = "second"
+)
+
+type unit struct {
+	metric string
+	factor float64
+}
+
+var units = map[string]unit{
+	"s":    {metricSecond, 1},
+	"sec":  {metricSecond, 1},
+	"secs": {metricSecond, 1},
+	"min":  {metricSecond, 60},
+	"mins": {metricSecond, 60},
+	"h":    {metricSecond, 3600},
+	"m":    {metricMeter, 1},
+	"km":   {metricMeter, 1000},
+	"mi":   {metricMeter, 1609.344},
+}
+
+// ParseError tells where in the text the parsing failed.
+type ParseError struct {
+	// Offset is the number of characters before the error, starting at 0.
+	Offset  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Offset+1, e.Message)
+}
+
+// Parse reads a workout written in the notation. The intensities are the ones the parts can refer to.
+func Parse(text string, intensities []models.Intensity) ([]models.WorkoutPart, error) {
+	p := &parser{text: []rune(text), intensities: intensities}
+
+	parts, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.atEnd() {
+		if p.peek() == ')' {
+			return nil, p.errorf("unmatched \")\"")
+		}
+		return nil, p.errorf("expected \"+\" between parts, found %q", p.peek())
+	}
+	// The repeat counts and nesting are checked while parsing, which leaves the total number of parts.
+	if err := models.CheckWorkoutParts(parts); err != nil {
+		return nil, &ParseError{Offset: 0, Message: err.Error()}
+	}
+	return parts, nil
+}
+
+type parser struct {
+	text        []rune
+	pos         int
+	intensities []models.Intensity
+	// depth is the number of repeat blocks around the current position.
+	depth int
+}
+
+func (p *parser) parseSequence() ([]models.WorkoutPart, error) {
+	var parts []models.WorkoutPart
+	for {
+		part, err := p.parsePart()
+		if err != nil {
+			return nil, err
+		}
+		part.Order = len(parts)
+		parts = append(parts, part)
+
+		p.skipSpace()
+		if p.atEnd() || !isSeparator(p.peek()) {
+			return parts, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parsePart() (models.WorkoutPart, error) {
+	p.skipSpace()
+	if p.atEnd() {
+		return models.WorkoutPart{}, p.errorf("expected a part, found the end of the text")
+	}
+
+	start := p.pos
+	number, isTime, err := p.parseQuantity()
+	if err != nil {
+		return models.WorkoutPart{}, err
+	}
+
+	p.skipSpace()
+	if !isTime && !p.atEnd() && isRepeatSign(p.peek()) {
+		return p.parseRepeat(start, number)
+	}
+
+	var metric string
+	var amount float64
+	if isTime {
+		metric, amount = metricSecond, number
+	} else {
+		unit, err := p.parseUnit()
+		if err != nil {
+			return models.WorkoutPart{}, err
+		}
+		metric, amount = unit.metric, number*unit.factor
+	}
+
+	intensity, err := p.parseIntensity()
+	if err != nil {
+		return models.WorkoutPart{}, err
+	}
+
+	return models.WorkoutPart{
+		Distance:  int(math.Round(amount)),
+		Metric:    metric,
+		Intensity: intensity,
+	}, nil
+}
+
+func (p *parser) parseRepeat(start int, count float64) (models.WorkoutPart, error) {
+	if count != math.Trunc(count) || count < 1 || count > models.MaxRepeat {
+		return models.WorkoutPart{}, &ParseError{Offset: start,
+			Message: fmt.Sprintf("the repeat count must be a whole number from 1 to %d", models.MaxRepeat)}
+	}
+	if p.depth >= models.MaxRepeatDepth {
+		return models.WorkoutPart{}, &ParseError{Offset: start, Message: models.ErrRepeatTooDeep.Error()}
+	}
+	p.pos++ // the repeat sign
+	p.depth++
+	defer func() { p.depth-- }()
+
+	p.skipSpace()
+	var parts []models.WorkoutPart
+	if !p.atEnd() && p.peek() == '(' {
+		p.pos++
+		var err error
+		parts, err = p.parseSequence()
+		if err != nil {
+			return models.WorkoutPart{}, err
+		}
+		p.skipSpace()
+		if p.atEnd() || p.peek() != ')' {
+			return models.WorkoutPart{}, p.errorf("expected \")\" to close the repeat block")
+		}
+		p.pos++
+	} else {
+		part, err := p.parsePart()
+		if err != nil {
+			return models.WorkoutPart{}, err
+		}
+		parts = []models.WorkoutPart{part}
+	}
+
+	return models.WorkoutPart{
+		Repeat: int(count),
+		Parts:  parts,
+	}, nil
+}
+
+// parseQuantity reads a number like "10" or "1.5", or a time like "1:30" or "1:05:00".
+func (p *parser) parseQuantity() (float64, bool, error) {
+	start := p.pos
+	for !p.atEnd() && (unicode.IsDigit(p.peek()) || p.peek() == '.' || p.peek() == ':') {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false, p.errorf("expected a number, found %q", p.peek())
+	}
+
+	text := string(p.text[start:p.pos])
+	if strings.Contains(text, ":") {
+		seconds := 0
+		for _, field := range strings.Split(text, ":") {
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				return 0, false, &ParseError{Offset: start, Message: fmt.Sprintf("%q is not a valid time", text)}
+			}
+			seconds = seconds*60 + value
+		}
+		return float64(seconds), true, nil
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false, &ParseError{Offset: start, Message: fmt.Sprintf("%q is not a valid number", text)}
+	}
+	return value, false, nil
+}
+
+func (p *parser) parseUnit() (unit, error) {
+	start := p.pos
+	for !p.atEnd() && unicode.IsLetter(p.peek()) {
+		p.pos++
+	}
+	name := strings.ToLower(string(p.text[start:p.pos]))
+	if name == "" {
+		return unit{}, &ParseError{Offset: start, Message: "expected a unit like min, s, m or km"}
+	}
+
+	unit, ok := units[name]
+	if !ok {
+		return unit, &ParseError{Offset: start, Message: fmt.Sprintf("unknown unit %q, expected s, min, h, m, km or mi", name)}
+	}
+	return unit, nil
+}
+
+// parseIntensity reads the rest of the part, up to the next separator or parenthesis.
+func (p *parser) parseIntensity() (models.Intensity, error) {
+	p.skipSpace()
+	start := p.pos
+	for !p.atEnd() && !isSeparator(p.peek()) && p.peek() != '(' && p.peek() != ')' {
+		p.pos++
+	}
+	name := strings.TrimSpace(string(p.text[start:p.pos]))
+	if name == "" {
+		return models.Intensity{}, &ParseError{Offset: start, Message: "expected an intensity"}
+	}
+
+	intensity, err := matchIntensity(name, p.intensities)
+	if err != nil {
+		return models.Intensity{}, &ParseError{Offset: start, Message: err.Error()}
+	}
+	return intensity, nil
+}
+
+func matchIntensity(name string, intensities []models.Intensity) (models.Intensity, error) {
+	var matches []models.Intensity
+	for _, intensity := range intensities {
+		if strings.EqualFold(intensity.Name, name) {
+			return intensity, nil
+		}
+		if strings.HasPrefix(strings.ToLower(intensity.Name), strings.ToLower(name)) {
+			matches = append(matches, intensity)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return models.Intensity{}, fmt.Errorf("unknown intensity %q", name)
+	default:
+		var names []string
+		for _, match := range matches {
+			names = append(names, match.Name)
+		}
+		return models.Intensity{}, fmt.Errorf("%q could be any of %s", name, strings.Join(names, ", "))
+	}
+}
+
+func (p *parser) skipSpace() {
+	for !p.atEnd() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *parser) peek() rune {
+	return p.text[p.pos]
+}
+
+func (p *parser) errorf(format string, args ...interface{}) *ParseError {
+	return &ParseError{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func isSeparator(r rune) bool {
+	return r == '+' || r == '/' || r == ','
+}
+
+func isRepeatSign(r rune) bool {
+	return r == 'x' || r == 'X' || r == '×' || r == '*'
+}
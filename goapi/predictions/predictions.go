@@ -0,0 +1,61 @@
+// Package predictions predicts race times, either through VDOT equivalence or through Riegel's formula.
+package predictions
+
+import (
+	"goapi/models"
+	"goapi/vdot"
+	"math"
+)
+
+// riegelExponent is the fatigue factor of Riegel's formula.
+const riegelExponent = 1.06
+
+// Race is a race distance in meters.
+type Race struct {
+	Name   string
+	Meters float64
+}
+
+// Prediction is the predicted finishing time of a race in seconds.
+type Prediction struct {
+	Race    Race
+	Seconds float64
+}
+
+// StandardRaces are the distances predictions are made for.
+var StandardRaces = []Race{
+	{Name: "1500m", Meters: 1500},
+	{Name: "Mile", Meters: 1609.344},
+	{Name: "3k", Meters: 3000},
+	{Name: "5k", Meters: 5000},
+	{Name: "10k", Meters: 10000},
+	{Name: "Half marathon", Meters: 21097.5},
+	{Name: "Marathon", Meters: 42195},
+}
+
+// ByVdot predicts the standard races from the times that give the same VDOT.
+func ByVdot(value float64) []Prediction {
+	if value <= 0 {
+		return []Prediction{}
+	}
+
+	predictions := make([]Prediction, 0, len(StandardRaces))
+	for _, race := range StandardRaces {
+		predictions = append(predictions, Prediction{Race: race, Seconds: vdot.RaceTime(value, race.Meters)})
+	}
+	return predictions
+}
+
+// ByRiegel predicts the standard races from a single result with Riegel's formula, T2 = T1 * (D2 / D1)^1.06.
+func ByRiegel(record models.Record) []Prediction {
+	if record.Distance <= 0 || record.Duration <= 0 {
+		return []Prediction{}
+	}
+
+	predictions := make([]Prediction, 0, len(StandardRaces))
+	for _, race := range StandardRaces {
+		seconds := float64(record.Duration) * math.Pow(race.Meters/float64(record.Distance), riegelExponent)
+		predictions = append(predictions, Prediction{Race: race, Seconds: seconds})
+	}
+	return predictions
+}
@@ -74,10 +74,26 @@ func (day *WorkoutIntensity) MapAirtableRecord(record airtable.AirtableRecord) e
 	day.Id = airtableWorkoutIntensity.Id
 	day.Metric = airtableWorkoutIntensity.Metric
 	day.Distance = airtableWorkoutIntensity.Distance
-	day.Coefficient = airtableWorkoutIntensity.Coefficient[0]
-	day.Intensity = airtableWorkoutIntensity.Intensity[0]
-	day.Description = airtableWorkoutIntensity.Description[0]
-	day.Name = airtableWorkoutIntensity.Name[0]
+	day.Coefficient = firstFloat(airtableWorkoutIntensity.Coefficient)
+	day.Intensity = firstString(airtableWorkoutIntensity.Intensity)
+	day.Description = firstString(airtableWorkoutIntensity.Description)
+	day.Name = firstString(airtableWorkoutIntensity.Name)
 
 	return nil
 }
+
+// firstString is the first value of a lookup field. Airtable leaves the field out when the linked intensity is
+// missing, so it may be empty.
+func firstString(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func firstFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0]
+}
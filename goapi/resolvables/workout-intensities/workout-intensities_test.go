@@ -0,0 +1,74 @@
+package workout_intensities
+
+import (
+	"context"
+	"goapi/airtable"
+	"goapi/airtable/airtabletest"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newTestServer serves the intensities of two workouts. The intensity fields are lookups of the linked
+// intensity, which Airtable sends as lists and leaves out when the link is missing.
+func newTestServer() *airtabletest.Server {
+	server := airtabletest.NewServer()
+	server.Add(airtable.WorkoutIntensity, "recA", map[string]interface{}{
+		"Id": "recA", "Workout": []string{"recW1"}, "distance": 600, "metric": "second",
+		"coefficient": []float64{0.65}, "intensity": []string{"recE"}, "name": []string{"Easy"},
+		"description": []string{"Conversational pace"},
+	})
+	server.Add(airtable.WorkoutIntensity, "recB", map[string]interface{}{
+		"Id": "recB", "Workout": []string{"recW2"}, "distance": 400, "metric": "meter",
+		"coefficient": []float64{1.05}, "intensity": []string{"recR"}, "name": []string{"Repetition"},
+	})
+	server.Add(airtable.WorkoutIntensity, "recC", map[string]interface{}{
+		"Id": "recC", "Workout": []string{"recW1"}, "distance": 1000, "metric": "meter",
+	})
+	return server
+}
+
+func TestGetByParentId(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	intensities, err := resolvable.GetByParentId(context.Background(), "recW1")
+	if err != nil {
+		t.Fatalf("GetByParentId failed: %v", err)
+	}
+	want := WorkoutIntensities{
+		{Id: "recA", Distance: 600, Coefficient: 0.65, Metric: "second", Intensity: "recE", Name: "Easy",
+			Description: "Conversational pace"},
+		{Id: "recC", Distance: 1000, Metric: "meter"},
+	}
+	if !reflect.DeepEqual(intensities, want) {
+		t.Errorf("GetByParentId = %+v, want %+v", intensities, want)
+	}
+}
+
+func TestGetByParentIdWithoutIntensities(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	intensities, err := resolvable.GetByParentId(context.Background(), "recW9")
+	if err != nil {
+		t.Fatalf("GetByParentId failed: %v", err)
+	}
+	if len(intensities) != 0 {
+		t.Errorf("GetByParentId = %+v, want no intensities", intensities)
+	}
+}
+
+func TestGetByParentIdFailure(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	server.Fail(http.StatusNotFound, "TABLE_NOT_FOUND", "")
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	_, err := resolvable.GetByParentId(context.Background(), "recW1")
+	if !airtable.IsNotFound(err) {
+		t.Errorf("GetByParentId returned %v, want the not found error of Airtable", err)
+	}
+}
@@ -0,0 +1,97 @@
+package workouts
+
+import (
+	"context"
+	"goapi/airtable"
+	"goapi/airtable/airtabletest"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newTestServer serves three workouts, with the fields named like in the base.
+func newTestServer() *airtabletest.Server {
+	server := airtabletest.NewServer()
+	server.Add(airtable.Workout, "rec1", map[string]interface{}{"Id": "rec1", "name": "Intervals", "distance": 8000})
+	server.Add(airtable.Workout, "rec2", map[string]interface{}{"Id": "rec2", "name": "Long run", "purpose": "Endurance"})
+	server.Add(airtable.Workout, "rec3", map[string]interface{}{"Id": "rec3", "name": "Recovery"})
+	return server
+}
+
+func TestGetAll(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	workouts, err := resolvable.GetAll(context.Background())
+	if err != nil {
+		t.Fatalf("GetAll failed: %v", err)
+	}
+	want := Workouts{
+		{Id: "rec1", Name: "Intervals", Distance: 8000},
+		{Id: "rec2", Name: "Long run", Purpose: "Endurance"},
+		{Id: "rec3", Name: "Recovery"},
+	}
+	if !reflect.DeepEqual(workouts, want) {
+		t.Errorf("GetAll = %+v, want %+v", workouts, want)
+	}
+}
+
+func TestGetByIds(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	workouts, err := resolvable.GetByIds(context.Background(), []string{"rec3", "rec1"})
+	if err != nil {
+		t.Fatalf("GetByIds failed: %v", err)
+	}
+	want := Workouts{
+		{Id: "rec1", Name: "Intervals", Distance: 8000},
+		{Id: "rec3", Name: "Recovery"},
+	}
+	if !reflect.DeepEqual(workouts, want) {
+		t.Errorf("GetByIds = %+v, want %+v", workouts, want)
+	}
+}
+
+func TestGet(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	workout, err := resolvable.Get(context.Background(), "rec2")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	want := Workout{Id: "rec2", Name: "Long run", Purpose: "Endurance"}
+	if workout != want {
+		t.Errorf("Get = %+v, want %+v", workout, want)
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+
+	_, err := resolvable.Get(context.Background(), "rec9")
+	if !airtable.IsNotFound(err) {
+		t.Errorf("Get of a missing workout returned %v, want a not found error", err)
+	}
+}
+
+func TestGetAllFailure(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	resolvable := NewResolvable(server.Client(context.Background()))
+	server.Fail(http.StatusUnprocessableEntity, "INVALID_REQUEST", "bad request")
+
+	workouts, err := resolvable.GetAll(context.Background())
+	if err == nil {
+		t.Fatal("GetAll succeeded, want the error of Airtable")
+	}
+	if len(workouts) != 0 {
+		t.Errorf("GetAll returned %d workouts with the error, want none", len(workouts))
+	}
+}
@@ -0,0 +1,123 @@
+// Package schedule places the relative weeks and days of plans on the calendar dates of a schedule.
+//
+// A schedule is anchored either on the start date, where the first day of the plan falls, or on the race date,
+// where the last day of the plan falls. Skipped weeks are left out of the calendar, and the gap they leave is
+// closed towards the anchor: the following weeks move a week earlier for schedules with a start date, and the
+// preceding weeks move a week later for schedules with a race date. The anchor itself never moves.
+package schedule
+
+import (
+	"context"
+	"goapi/models"
+	"time"
+)
+
+const daysPerWeek = 7
+
+// Day is a day of a plan placed on the calendar.
+type Day struct {
+	Date     time.Time
+	Schedule models.PlanSchedule
+	Week     models.Week
+	Day      models.Day
+}
+
+// PlanReader reads the weeks and days of plans, like the database client.
+type PlanReader interface {
+	GetWeeksForPlan(ctx context.Context, planId string) ([]models.Week, error)
+	GetDaysForWeek(ctx context.Context, weekId string) ([]models.Day, error)
+}
+
+// Days lists the planned days of a schedule in date order, leaving out the skipped weeks.
+func Days(ctx context.Context, reader PlanReader, schedule models.PlanSchedule) ([]Day, error) {
+	weeks, err := reader.GetWeeksForPlan(ctx, schedule.PlanId)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := []Day{}
+	for _, week := range weeks {
+		if IsSkipped(schedule, week.Order) {
+			continue
+		}
+		days, err := reader.GetDaysForWeek(ctx, week.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			date, _ := Date(schedule, len(weeks), week, day)
+			scheduled = append(scheduled, Day{Date: date, Schedule: schedule, Week: week, Day: day})
+		}
+	}
+	return scheduled, nil
+}
+
+// Start is the date of the first day of the first scheduled week, as midnight UTC.
+func Start(schedule models.PlanSchedule, numberOfWeeks int) time.Time {
+	if schedule.RaceDate.IsZero() {
+		return date(schedule.StartDate)
+	}
+	weeks := numberOfWeeks - skippedWeeks(schedule, numberOfWeeks)
+	return date(schedule.RaceDate).AddDate(0, 0, 1-weeks*daysPerWeek)
+}
+
+// End is the date of the last day of the last scheduled week.
+func End(schedule models.PlanSchedule, numberOfWeeks int) time.Time {
+	weeks := numberOfWeeks - skippedWeeks(schedule, numberOfWeeks)
+	return Start(schedule, numberOfWeeks).AddDate(0, 0, weeks*daysPerWeek-1)
+}
+
+// Date is the calendar date of a day of a scheduled plan. The second return value is false when the week of
+// the day is skipped.
+func Date(schedule models.PlanSchedule, numberOfWeeks int, week models.Week, day models.Day) (time.Time, bool) {
+	if IsSkipped(schedule, week.Order) {
+		return time.Time{}, false
+	}
+	position := week.Order
+	for _, skipped := range distinctSkipped(schedule, numberOfWeeks) {
+		if skipped < week.Order {
+			position--
+		}
+	}
+	return Start(schedule, numberOfWeeks).AddDate(0, 0, position*daysPerWeek+day.Day), true
+}
+
+// IsSkipped tells whether a week of the plan is left out of the schedule.
+func IsSkipped(schedule models.PlanSchedule, weekOrder int) bool {
+	for _, skipped := range schedule.SkippedWeeks {
+		if skipped == weekOrder {
+			return true
+		}
+	}
+	return false
+}
+
+// Sequence is the number of changes to a scheduled plan. It grows whenever the plan or the schedule changes, so
+// calendar clients know to update the days they have. Changes to the workouts of a day are counted by the
+// calendar feed, which adds the revision of the event.
+func Sequence(schedule models.PlanSchedule, plan models.Plan) int {
+	return schedule.Revision + plan.Revision
+}
+
+func skippedWeeks(schedule models.PlanSchedule, numberOfWeeks int) int {
+	return len(distinctSkipped(schedule, numberOfWeeks))
+}
+
+// distinctSkipped lists the skipped weeks that are in the plan, once each. Weeks that were skipped and then
+// removed from the plan are ignored.
+func distinctSkipped(schedule models.PlanSchedule, numberOfWeeks int) []int {
+	seen := map[int]bool{}
+	distinct := []int{}
+	for _, skipped := range schedule.SkippedWeeks {
+		if skipped < 0 || skipped >= numberOfWeeks || seen[skipped] {
+			continue
+		}
+		seen[skipped] = true
+		distinct = append(distinct, skipped)
+	}
+	return distinct
+}
+
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
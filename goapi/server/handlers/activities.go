@@ -0,0 +1,122 @@
+// Package handlers serves the HTTP endpoints next to the GraphQL endpoint, like file uploads and downloads.
+package handlers
+
+import (
+	"goapi/appcontext"
+	"goapi/database"
+	"goapi/logger"
+	"goapi/models"
+	"goapi/server/problems"
+	"goapi/server/responsewriter"
+	"goapi/tracks"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxUploadBytes is the largest activity file that is accepted. A marathon recorded every second is a few MB.
+const maxUploadBytes = 32 << 20
+
+const uploadField = "file"
+
+type activityResponse struct {
+	Id               string `json:"id"`
+	StartTime        string `json:"startTime"`
+	Duration         int    `json:"duration"`
+	MovingTime       int    `json:"movingTime"`
+	Distance         int    `json:"distance"`
+	ElevationGain    int    `json:"elevationGain"`
+	AverageHeartRate int    `json:"averageHeartRate,omitempty"`
+	MaxHeartRate     int    `json:"maxHeartRate,omitempty"`
+	Source           string `json:"source"`
+}
+
+// UploadActivity stores an uploaded GPX, TCX or FIT file as an activity of the logged in user. The file is either
+// the "file" field of a multipart form, or the body of the request.
+func UploadActivity(dbClient database.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		abort := responsewriter.AbortHandler(w)
+
+		profile, ok := loggedInProfile(r)
+		if !ok {
+			abort(ctx, problems.ErrNotLoggedIn)
+			return
+		}
+
+		if r.ContentLength > maxUploadBytes {
+			abort(ctx, problems.ErrFileTooLarge)
+			return
+		}
+		data, err := readUpload(w, r)
+		if err != nil {
+			log.WithError(err).Warn("Could not read the uploaded file")
+			problem := problems.ErrInvalidActivityFile
+			problem.Detail = err.Error()
+			abort(ctx, problem)
+			return
+		}
+
+		track, err := tracks.Parse(data)
+		if err != nil {
+			log.WithError(err).Warn("Could not parse the uploaded file")
+			problem := problems.ErrInvalidActivityFile
+			problem.Detail = err.Error()
+			abort(ctx, problem)
+			return
+		}
+
+		activity := track.Activity()
+		activity.ProfileId = profile.Id
+		activity, err = dbClient.SaveRecordedActivity(ctx, activity, track.Laps, track.Samples)
+		if err != nil {
+			abort(ctx, problems.ErrUnexpected)
+			return
+		}
+
+		log.Info("Activity uploaded")
+		responsewriter.WriteJSON(ctx, w, http.StatusCreated, newActivityResponse(activity))
+	}
+}
+
+func loggedInProfile(r *http.Request) (models.Profile, bool) {
+	authenticated, err := appcontext.UserAuthenticated(r.Context())
+	if err != nil || !authenticated {
+		return models.Profile{}, false
+	}
+	profile, err := appcontext.Profile(r.Context())
+	return profile, err == nil
+}
+
+func readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return ioutil.ReadAll(r.Body)
+	}
+
+	file, _, err := r.FormFile(uploadField)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return ioutil.ReadAll(file)
+}
+
+func newActivityResponse(activity models.Activity) activityResponse {
+	return activityResponse{
+		Id:               activity.Id,
+		StartTime:        activity.StartTime.Format(time.RFC3339),
+		Duration:         activity.Duration,
+		MovingTime:       activity.MovingTime,
+		Distance:         activity.Distance,
+		ElevationGain:    activity.ElevationGain,
+		AverageHeartRate: activity.AverageHeartRate,
+		MaxHeartRate:     activity.MaxHeartRate,
+		Source:           activity.Source,
+	}
+}
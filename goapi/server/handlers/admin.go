@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"github.com/gorilla/mux"
+	"goapi/airtable"
+	"goapi/logger"
+	"goapi/server/problems"
+	"goapi/server/responsewriter"
+	"net/http"
+)
+
+// adminSecretHeader carries the admin secret. It is not the Authorization header, which the authentication
+// middleware reads as a user token.
+const adminSecretHeader = "X-Admin-Secret"
+
+// InvalidateAirtableTable drops the cached results of an Airtable table, so changes made in Airtable show up
+// right away. It needs the admin secret, and is turned off when no secret is configured.
+func InvalidateAirtableTable(cache *airtable.CachingClient, adminSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		abort := responsewriter.AbortHandler(w)
+		log := logger.FromContext(ctx)
+
+		if !isAdmin(r, adminSecret) {
+			log.Warn("Invalidating the Airtable cache without the admin secret")
+			abort(ctx, problems.ErrNotAdmin)
+			return
+		}
+		table, ok := airtable.ParseTable(mux.Vars(r)["table"])
+		if !ok {
+			abort(ctx, problems.ErrUnknownTable)
+			return
+		}
+
+		cache.Invalidate(table)
+		log.WithField("table", table).Info("Invalidated the Airtable cache")
+		responsewriter.WriteJSON(ctx, w, http.StatusOK, map[string]string{"invalidated": string(table)})
+	}
+}
+
+func isAdmin(r *http.Request, adminSecret string) bool {
+	if adminSecret == "" {
+		return false
+	}
+	given := r.Header.Get(adminSecretHeader)
+	return subtle.ConstantTimeCompare([]byte(given), []byte(adminSecret)) == 1
+}
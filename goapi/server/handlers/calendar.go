@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/export"
+	"goapi/ical"
+	"goapi/logger"
+	"goapi/models"
+	"goapi/schedule"
+	"goapi/server/problems"
+	"goapi/server/responsewriter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uidDomain is the domain of the app, which makes the UIDs of the events globally unique. The UIDs identify the
+// events in subscribed calendars, so it must never change.
+const uidDomain = "treningsplan.s33.no"
+
+// CalendarFeed serves the scheduled plans of a profile as an iCalendar feed, with an event for every planned
+// day. Calendar clients can not log in, so the feed is found by the secret token in the path instead.
+func CalendarFeed(dbClient database.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		abort := responsewriter.AbortHandler(w)
+
+		profile, err := dbClient.GetProfileByCalendarToken(ctx, mux.Vars(r)["token"])
+		if database.IsNotFound(err) {
+			abort(ctx, problems.ErrCalendarNotFound)
+			return
+		}
+		if err != nil {
+			abort(ctx, problems.ErrUnexpected)
+			return
+		}
+
+		calendar, err := profileCalendar(ctx, dbClient, profile)
+		if err != nil {
+			abort(ctx, problems.ErrUnexpected)
+			return
+		}
+
+		feed := calendar.Encode(time.Now())
+		w.Header().Set("Content-Type", "text/calendar; charset=UTF-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(feed)))
+		_, err = w.Write(feed)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).Error("Writing the calendar failed.")
+		}
+	}
+}
+
+func profileCalendar(ctx context.Context, dbClient database.Client, profile models.Profile) (ical.Calendar, error) {
+	calendar := ical.Calendar{Name: "S33 Treningsplan – " + strings.TrimSpace(profile.FirstName+" "+profile.LastName)}
+
+	estimator, err := profileEstimator(ctx, dbClient, profile)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+	schedules, err := dbClient.GetSchedulesForProfile(ctx, profile.Id)
+	if err != nil {
+		return ical.Calendar{}, err
+	}
+
+	for _, planSchedule := range schedules {
+		if calendar.TimeZone == "" {
+			calendar.TimeZone = planSchedule.TimeZone
+		}
+		events, err := scheduleEvents(ctx, dbClient, planSchedule, profile, estimator)
+		if err != nil {
+			return ical.Calendar{}, err
+		}
+		calendar.Events = append(calendar.Events, events...)
+	}
+	return calendar, nil
+}
+
+// scheduleEvents makes an event of every day of a scheduled plan that has workouts. The events are named after
+// the workouts, and describe their steps at the paces of the profile.
+func scheduleEvents(ctx context.Context, dbClient database.Client, planSchedule models.PlanSchedule, profile models.Profile, estimator estimates.Estimator) ([]ical.Event, error) {
+	plan, err := dbClient.GetPlan(ctx, planSchedule.PlanId)
+	if err != nil {
+		return nil, err
+	}
+	days, err := schedule.Days(ctx, dbClient, planSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	// The workouts and parts of all the days are loaded up front, as calendar clients poll the feed often.
+	allDayIds := []string{}
+	for _, day := range days {
+		allDayIds = append(allDayIds, day.Day.Id)
+	}
+	workoutsByDay, err := dbClient.GetWorkoutsForDays(ctx, allDayIds)
+	if err != nil {
+		return nil, err
+	}
+	workoutIds := []string{}
+	for _, workouts := range workoutsByDay {
+		for _, workout := range workouts {
+			workoutIds = append(workoutIds, workout.Id)
+		}
+	}
+	partsByWorkout, err := dbClient.GetWorkoutPartsForWorkouts(ctx, workoutIds)
+	if err != nil {
+		return nil, err
+	}
+
+	events, dayIds := []ical.Event{}, []string{}
+	for _, day := range days {
+		workouts := workoutsByDay[day.Day.Id]
+		if len(workouts) == 0 {
+			continue
+		}
+
+		names, descriptions := []string{}, []string{}
+		for _, workout := range workouts {
+			steps := export.Build(workout, partsByWorkout[workout.Id], profile, estimator).Steps
+			names = append(names, workout.Name)
+			descriptions = append(descriptions, workout.Name+"\n"+strings.TrimSpace(export.Text(steps))+"\n")
+		}
+		descriptions = append(descriptions, "Week "+strconv.Itoa(day.Week.Order+1)+" of "+plan.Name)
+
+		events = append(events, ical.Event{
+			UID:         planSchedule.Id + "-" + day.Day.Id + "@" + uidDomain,
+			Date:        day.Date,
+			Summary:     strings.Join(names, " + "),
+			Description: strings.Join(descriptions, "\n"),
+		})
+		dayIds = append(dayIds, day.Day.Id)
+	}
+
+	// Workouts and paces change the events without changing the plan, which the hashes of the events catch.
+	hashes := map[string]string{}
+	for i, event := range events {
+		hashes[dayIds[i]] = event.ContentHash()
+	}
+	revisions, err := dbClient.UpdateCalendarEvents(ctx, planSchedule.Id, hashes)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Sequence = schedule.Sequence(planSchedule, plan) + revisions[dayIds[i]]
+	}
+	return events, nil
+}
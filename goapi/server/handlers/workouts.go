@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/mux"
+	"goapi/database"
+	"goapi/estimates"
+	"goapi/export"
+	"goapi/logger"
+	"goapi/models"
+	"goapi/server/problems"
+	"goapi/server/responsewriter"
+	"goapi/vdot"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const formatParameter = "format"
+
+// ExportWorkout serves a workout in the format of the format query parameter, like zwo or json.
+func ExportWorkout(dbClient database.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, ok := export.FormatByName(r.URL.Query().Get(formatParameter))
+		if !ok {
+			problem := problems.ErrUnknownExportFormat
+			problem.Detail = "The format must be one of " + strings.Join(export.FormatNames(), ", ")
+			responsewriter.AbortHandler(w)(r.Context(), problem)
+			return
+		}
+		writeWorkout(w, r, dbClient, format)
+	}
+}
+
+// DownloadWorkout serves a workout in one format, like the FIT files that watches read.
+func DownloadWorkout(dbClient database.Client, formatName string) http.HandlerFunc {
+	format, ok := export.FormatByName(formatName)
+	if !ok {
+		panic("unknown export format " + formatName)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeWorkout(w, r, dbClient, format)
+	}
+}
+
+// writeWorkout writes a workout as a file. The targets are the paces and heart rates of the logged in user, or
+// of the creator of the workout when nobody is logged in.
+func writeWorkout(w http.ResponseWriter, r *http.Request, dbClient database.Client, format export.Format) {
+	ctx := r.Context()
+	abort := responsewriter.AbortHandler(w)
+
+	workout, parts, ok := workoutFromRequest(w, r, dbClient)
+	if !ok {
+		return
+	}
+	profile, estimator, err := viewerTargets(r, dbClient, workout.CreatedBy)
+	if err != nil {
+		abort(ctx, problems.ErrUnexpected)
+		return
+	}
+
+	file, err := format.Write(export.Build(workout, parts, profile, estimator))
+	if err == export.ErrNotExportable {
+		abort(ctx, problems.ErrWorkoutNotExportable)
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Exporting the workout failed.")
+		abort(ctx, problems.ErrUnexpected)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName(workout, format.Extension)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file)))
+	_, err = w.Write(file)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("Writing the workout file failed.")
+	}
+}
+
+// workoutFromRequest fetches the workout and the parts of the id in the path. When it fails, it writes the
+// error response and returns false.
+func workoutFromRequest(w http.ResponseWriter, r *http.Request, dbClient database.Client) (models.Workout, []models.WorkoutPart, bool) {
+	ctx := r.Context()
+	abort := responsewriter.AbortHandler(w)
+
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.FromString(id); err != nil {
+		abort(ctx, problems.ErrWorkoutNotFound)
+		return models.Workout{}, nil, false
+	}
+	workout, err := dbClient.GetWorkout(ctx, id)
+	if database.IsNotFound(err) {
+		abort(ctx, problems.ErrWorkoutNotFound)
+		return models.Workout{}, nil, false
+	}
+	if err != nil {
+		abort(ctx, problems.ErrUnexpected)
+		return models.Workout{}, nil, false
+	}
+	parts, err := dbClient.GetWorkoutPartsForWorkout(ctx, id)
+	if err != nil {
+		abort(ctx, problems.ErrUnexpected)
+		return models.Workout{}, nil, false
+	}
+	return workout, parts, true
+}
+
+// viewerTargets finds the profile whose heart rates and paces the targets of a workout are based on: the logged
+// in user, or the creator of the workout.
+func viewerTargets(r *http.Request, dbClient database.Client, creatorId string) (models.Profile, estimates.Estimator, error) {
+	ctx := r.Context()
+	profile, ok := loggedInProfile(r)
+	if !ok {
+		var err error
+		profile, err = dbClient.GetProfile(ctx, creatorId)
+		if err != nil {
+			return models.Profile{}, estimates.Estimator{}, err
+		}
+	}
+
+	estimator, err := profileEstimator(ctx, dbClient, profile)
+	if err != nil {
+		return models.Profile{}, estimates.Estimator{}, err
+	}
+	return profile, estimator, nil
+}
+
+func profileEstimator(ctx context.Context, dbClient database.Client, profile models.Profile) (estimates.Estimator, error) {
+	records, err := dbClient.GetRecords(ctx, profile.Id)
+	if err != nil {
+		return estimates.Estimator{}, err
+	}
+	return estimates.New(vdot.Current(profile, records, time.Now().AddDate(0, -vdot.RecentMonths, 0))), nil
+}
+
+// fileName names a downloaded file after the workout, keeping only the characters that are safe in file names.
+func fileName(workout models.Workout, extension string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, workout.Name)
+	if strings.TrimSpace(name) == "" {
+		name = "workout"
+	}
+	return strings.TrimSpace(name) + "." + extension
+}
@@ -11,12 +11,9 @@ import (
 	gqlschema "goapi/gql-schema"
 	"goapi/jwktokenvalidator"
 	"goapi/logger"
-	"goapi/resolvables/days"
-	"goapi/resolvables/intensity-zones"
-	"goapi/resolvables/plans"
-	"goapi/resolvables/weeks"
 	workout_intensities "goapi/resolvables/workout-intensities"
 	"goapi/resolvables/workouts"
+	"goapi/server/handlers"
 	"goapi/server/mw"
 	"net/http"
 	"os"
@@ -46,7 +43,11 @@ func main() {
 	log := logger.FromContext(startupCtx)
 
 	log.Info("setting up airtable client")
-	airtableClient, err := airtable.NewClient(startupCtx, cfg.AirtableSecret)
+	airtableClient, err := airtable.NewClient(startupCtx, cfg.AirtableSecret,
+		airtable.BaseUrl(cfg.AirtableBaseUrl),
+		airtable.BaseId(cfg.AirtableBaseId),
+		airtable.Timeout(cfg.AirtableTimeout),
+	)
 	if err != nil {
 		log.WithError(err).Panic("failed to create airtable client")
 	}
@@ -70,17 +71,20 @@ func main() {
 	}
 	jwtTokenValidator := jwktokenvalidator.NewJwtTokenValidator(publicKeyStore)
 
-	resolvableIntensity := intensityzones.NewResolvable(airtableClient, databaseClient)
-	resolvableWorkout := workouts.NewResolvable(airtableClient)
-	resolvableDay := days.NewResolvable(airtableClient)
-	resolvableWeek := weeks.NewResolvable(airtableClient)
-	resolvablePlan := plans.NewResolvable(airtableClient)
-	resolvableWorkoutIntensities := workout_intensities.NewResolvable(airtableClient)
+	// The workout intensities look up their names and coefficients in the intensity table, and are kept for a
+	// shorter time so that edits to the intensities show up sooner.
+	airtableCache := airtable.NewCachingClient(airtableClient,
+		airtable.DefaultTTL(cfg.AirtableCacheTtl),
+		airtable.TableTTL(airtable.WorkoutIntensity, cfg.AirtableCacheTtl/5),
+		airtable.MaxEntries(cfg.AirtableCacheSize),
+	)
+
+	resolvableWorkout := workouts.NewResolvable(airtableCache)
+	resolvableWorkoutIntensities := workout_intensities.NewResolvable(airtableCache)
 
 	log.Info("setting up graphql schema")
 	schema, err := gqlschema.InitSchema(
-		resolvableIntensity, resolvableWorkout, resolvableDay, resolvableWeek, resolvablePlan,
-		resolvableWorkoutIntensities, databaseClient,
+		resolvableWorkout, resolvableWorkoutIntensities, databaseClient,
 	)
 	if err != nil {
 		log.WithError(err).Panic("failed to create new schema")
@@ -110,6 +114,11 @@ func main() {
 	)
 
 	router.Handle("/", h)
+	router.Handle("/activities", handlers.UploadActivity(databaseClient)).Methods(http.MethodPost)
+	router.Handle("/workouts/{id}.fit", handlers.DownloadWorkout(databaseClient, "fit")).Methods(http.MethodGet)
+	router.Handle("/workouts/{id}/export", handlers.ExportWorkout(databaseClient)).Methods(http.MethodGet)
+	router.Handle("/calendar/{token}.ics", handlers.CalendarFeed(databaseClient)).Methods(http.MethodGet)
+	router.Handle("/admin/airtable/{table}/invalidate", handlers.InvalidateAirtableTable(airtableCache, cfg.AdminSecret)).Methods(http.MethodPost)
 
 	err = http.ListenAndServe(":8080", router)
 	if err != nil {
@@ -45,4 +45,54 @@ var (
 		Title:      "Not authorized.",
 		StatusCode: http.StatusUnauthorized,
 	}
+	ErrNotLoggedIn = Problem{
+		Type:       errTypePrefix + "not-logged-in",
+		Title:      "The user must be logged in.",
+		StatusCode: http.StatusUnauthorized,
+	}
+	ErrInvalidActivityFile = Problem{
+		Type:       errTypePrefix + "invalid-activity-file",
+		Title:      "The activity file could not be read.",
+		StatusCode: http.StatusBadRequest,
+	}
+	ErrFileTooLarge = Problem{
+		Type:       errTypePrefix + "file-too-large",
+		Title:      "The file is too large.",
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+	ErrWorkoutNotFound = Problem{
+		Type:       errTypePrefix + "workout-not-found",
+		Title:      "The workout does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	ErrUnknownExportFormat = Problem{
+		Type:       errTypePrefix + "unknown-export-format",
+		Title:      "The workout can not be exported to that format.",
+		StatusCode: http.StatusBadRequest,
+	}
+	ErrWorkoutNotExportable = Problem{
+		Type:       errTypePrefix + "workout-not-exportable",
+		Title:      "The workout lacks the durations or targets that the format needs.",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+	ErrCalendarNotFound = Problem{
+		Type:       errTypePrefix + "calendar-not-found",
+		Title:      "The calendar does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	ErrNotAdmin = Problem{
+		Type:       errTypePrefix + "not-admin",
+		Title:      "The admin secret is missing or wrong.",
+		StatusCode: http.StatusForbidden,
+	}
+	ErrUnknownTable = Problem{
+		Type:       errTypePrefix + "unknown-table",
+		Title:      "The Airtable table does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	ErrUnexpected = Problem{
+		Type:       errTypePrefix + "unexpected-error",
+		Title:      genericErrorTitle,
+		StatusCode: http.StatusInternalServerError,
+	}
 )
@@ -0,0 +1,28 @@
+package responsewriter
+
+import (
+	"context"
+	"encoding/json"
+	"goapi/logger"
+	"goapi/server/problems"
+	"net/http"
+)
+
+// WriteJSON writes the value as the JSON body of the response.
+func WriteJSON(ctx context.Context, w http.ResponseWriter, statusCode int, value interface{}) {
+	log := logger.FromContext(ctx)
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		log.WithError(err).Error("Marshalling a response object to JSON failed.")
+		AbortHandler(w)(ctx, problems.ErrUnexpected)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(statusCode)
+	_, err = w.Write(body)
+	if err != nil {
+		log.WithError(err).Error("Writing response body failed.")
+	}
+}
@@ -0,0 +1,40 @@
+// Package targets resolves the relative targets of intensities, like percentages of max heart rate, to the
+// absolute heart rates and power of a profile.
+package targets
+
+import "goapi/models"
+
+// HeartRate is the heart rate range, in beats per minute, of an intensity for a profile. The heart rate reserve
+// target is used with the Karvonen formula when the profile has both a max and a resting heart rate, and the max
+// heart rate target otherwise. The second return value is false when neither can be resolved.
+func HeartRate(targets models.IntensityTargets, profile models.Profile) (models.Range, bool) {
+	if targets.HeartRateReserve.IsSet() && profile.MaxHeartRate > 0 && profile.RestingHeartRate > 0 {
+		resting := float64(profile.RestingHeartRate)
+		reserve := float64(profile.MaxHeartRate - profile.RestingHeartRate)
+		return models.Range{
+			Min: resting + reserve*targets.HeartRateReserve.Min/100,
+			Max: resting + reserve*targets.HeartRateReserve.Max/100,
+		}, true
+	}
+
+	if targets.HeartRateMax.IsSet() && profile.MaxHeartRate > 0 {
+		return percentOf(targets.HeartRateMax, profile.MaxHeartRate), true
+	}
+
+	return models.Range{}, false
+}
+
+// Power is the power range, in watts, of an intensity for a profile with a threshold power.
+func Power(targets models.IntensityTargets, profile models.Profile) (models.Range, bool) {
+	if !targets.Power.IsSet() || profile.ThresholdPower <= 0 {
+		return models.Range{}, false
+	}
+	return percentOf(targets.Power, profile.ThresholdPower), true
+}
+
+func percentOf(percentages models.Range, value int) models.Range {
+	return models.Range{
+		Min: percentages.Min / 100 * float64(value),
+		Max: percentages.Max / 100 * float64(value),
+	}
+}
@@ -0,0 +1,82 @@
+package tracks
+
+import (
+	"goapi/fit"
+	"goapi/models"
+	"math"
+)
+
+const sourceFit = "fit"
+
+// ParseFIT reads the records, laps and sessions of a binary FIT file. The totals of the sessions are kept as
+// the summary of the track, since the device computes them from more data than the records hold.
+func ParseFIT(data []byte) (Track, error) {
+	file, err := fit.Decode(data)
+	if err != nil {
+		return Track{}, err
+	}
+
+	track := Track{Source: sourceFit}
+	for _, record := range file.Records {
+		track.Samples = append(track.Samples, models.Sample{
+			Time:         record.Timestamp,
+			HasPosition:  record.HasPosition,
+			Latitude:     record.Latitude,
+			Longitude:    record.Longitude,
+			HasElevation: record.HasAltitude,
+			Elevation:    record.Altitude,
+			Distance:     record.Distance,
+			HeartRate:    record.HeartRate,
+			Cadence:      record.Cadence,
+			Power:        record.Power,
+		})
+	}
+	for _, lap := range file.Laps {
+		track.Laps = append(track.Laps, models.Lap{
+			StartTime: lap.StartTime,
+			Duration:  firstPositiveFloat(lap.TimerTime, lap.ElapsedTime),
+			Distance:  lap.Distance,
+		})
+	}
+	if len(file.Sessions) > 0 {
+		track.Summary = sessionSummary(file.Sessions)
+	}
+
+	return finish(track)
+}
+
+// sessionSummary adds up the sessions of a multisport activity.
+func sessionSummary(sessions []fit.Session) *models.Activity {
+	summary := models.Activity{StartTime: sessions[0].StartTime}
+	elapsed, timer, distance, heartBeats, heartRateTime := 0.0, 0.0, 0.0, 0.0, 0.0
+	for _, session := range sessions {
+		elapsed += session.ElapsedTime
+		timer += session.TimerTime
+		distance += session.Distance
+		summary.ElevationGain += session.TotalAscent
+		if session.AverageHeartRate > 0 {
+			heartBeats += float64(session.AverageHeartRate) * session.TimerTime
+			heartRateTime += session.TimerTime
+		}
+		if session.MaxHeartRate > summary.MaxHeartRate {
+			summary.MaxHeartRate = session.MaxHeartRate
+		}
+	}
+
+	summary.Duration = int(math.Round(elapsed))
+	summary.MovingTime = int(math.Round(timer))
+	summary.Distance = int(math.Round(distance))
+	if heartRateTime > 0 {
+		summary.AverageHeartRate = int(math.Round(heartBeats / heartRateTime))
+	}
+	return &summary
+}
+
+func firstPositiveFloat(values ...float64) float64 {
+	for _, value := range values {
+		if value > 0 {
+			return value
+		}
+	}
+	return 0
+}
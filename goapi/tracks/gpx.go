@@ -0,0 +1,76 @@
+package tracks
+
+import (
+	"encoding/xml"
+	"goapi/models"
+	"time"
+)
+
+const sourceGpx = "gpx"
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Latitude  float64   `xml:"lat,attr"`
+	Longitude float64   `xml:"lon,attr"`
+	Elevation *float64  `xml:"ele"`
+	Time      time.Time `xml:"time"`
+	// Heart rate, cadence and power are recorded in the Garmin track point extension, or as plain extensions.
+	Extensions struct {
+		HeartRate      int `xml:"TrackPointExtension>hr"`
+		Cadence        int `xml:"TrackPointExtension>cad"`
+		PlainHeartRate int `xml:"hr"`
+		PlainCadence   int `xml:"cad"`
+		Power          int `xml:"power"`
+	} `xml:"extensions"`
+}
+
+// ParseGPX reads the track points of a GPX 1.1 file. GPX files have no laps. The samples are numbered by
+// segment, as the recording is paused between the segments of a track, and between tracks.
+func ParseGPX(data []byte) (Track, error) {
+	var file gpxFile
+	err := xml.Unmarshal(data, &file)
+	if err != nil {
+		return Track{}, err
+	}
+
+	track := Track{Source: sourceGpx}
+	segmentNumber := 0
+	for _, gpxTrack := range file.Tracks {
+		for _, segment := range gpxTrack.Segments {
+			segmentNumber++
+			for _, point := range segment.Points {
+				sample := models.Sample{
+					Time:        point.Time,
+					HasPosition: true,
+					Latitude:    point.Latitude,
+					Longitude:   point.Longitude,
+					HeartRate:   firstPositive(point.Extensions.HeartRate, point.Extensions.PlainHeartRate),
+					Cadence:     firstPositive(point.Extensions.Cadence, point.Extensions.PlainCadence),
+					Power:       point.Extensions.Power,
+					Segment:     segmentNumber,
+				}
+				if point.Elevation != nil {
+					sample.HasElevation, sample.Elevation = true, *point.Elevation
+				}
+				track.Samples = append(track.Samples, sample)
+			}
+		}
+	}
+	return finish(track)
+}
+
+func firstPositive(values ...int) int {
+	for _, value := range values {
+		if value > 0 {
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package tracks
+
+import (
+	"goapi/models"
+	"time"
+)
+
+// Splits divides the samples of an activity into laps of the given number of meters. The last split is
+// usually shorter. The times where the splits start are interpolated between the samples.
+func Splits(samples []models.Sample, meters float64) []models.Lap {
+	if len(samples) < 2 || meters <= 0 {
+		return []models.Lap{}
+	}
+
+	splits := []models.Lap{}
+	start := samples[0].Time
+	startDistance := samples[0].Distance
+	first := 0
+	for i := 1; i < len(samples); i++ {
+		for samples[i].Distance-startDistance >= meters {
+			end := interpolateTime(samples[i-1], samples[i], startDistance+meters)
+			splits = append(splits, split(len(splits), start, end, meters, samples[first:i+1]))
+			start, startDistance, first = end, startDistance+meters, i
+		}
+	}
+
+	last := samples[len(samples)-1]
+	if remaining := last.Distance - startDistance; remaining > 0 {
+		splits = append(splits, split(len(splits), start, last.Time, remaining, samples[first:]))
+	}
+	return splits
+}
+
+func split(order int, start, end time.Time, meters float64, samples []models.Sample) models.Lap {
+	lap := models.Lap{
+		Order:     order,
+		StartTime: start,
+		Duration:  end.Sub(start).Seconds(),
+		Distance:  meters,
+	}
+	lap.AverageHeartRate, lap.MaxHeartRate = heartRate(samples)
+	return lap
+}
+
+// interpolateTime is the time between two samples where the distance was covered.
+func interpolateTime(before, after models.Sample, distance float64) time.Time {
+	covered := after.Distance - before.Distance
+	if covered <= 0 {
+		return after.Time
+	}
+	fraction := (distance - before.Distance) / covered
+	return before.Time.Add(time.Duration(fraction * float64(after.Time.Sub(before.Time))))
+}
@@ -0,0 +1,72 @@
+package tracks
+
+import (
+	"encoding/xml"
+	"goapi/models"
+	"time"
+)
+
+const sourceTcx = "tcx"
+
+type tcxFile struct {
+	Activities []struct {
+		Laps []struct {
+			StartTime        time.Time `xml:"StartTime,attr"`
+			TotalTimeSeconds float64   `xml:"TotalTimeSeconds"`
+			DistanceMeters   float64   `xml:"DistanceMeters"`
+			Points           []struct {
+				Time     time.Time `xml:"Time"`
+				Position *struct {
+					Latitude  float64 `xml:"LatitudeDegrees"`
+					Longitude float64 `xml:"LongitudeDegrees"`
+				} `xml:"Position"`
+				Altitude       *float64 `xml:"AltitudeMeters"`
+				DistanceMeters float64  `xml:"DistanceMeters"`
+				HeartRate      int      `xml:"HeartRateBpm>Value"`
+				Cadence        int      `xml:"Cadence"`
+				Extensions     struct {
+					RunCadence int `xml:"TPX>RunCadence"`
+					Watts      int `xml:"TPX>Watts"`
+				} `xml:"Extensions"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+// ParseTCX reads the laps and track points of a Garmin Training Center file.
+func ParseTCX(data []byte) (Track, error) {
+	var file tcxFile
+	err := xml.Unmarshal(data, &file)
+	if err != nil {
+		return Track{}, err
+	}
+
+	track := Track{Source: sourceTcx}
+	for _, activity := range file.Activities {
+		for _, lap := range activity.Laps {
+			track.Laps = append(track.Laps, models.Lap{
+				StartTime: lap.StartTime,
+				Duration:  lap.TotalTimeSeconds,
+				Distance:  lap.DistanceMeters,
+			})
+			for _, point := range lap.Points {
+				sample := models.Sample{
+					Time:      point.Time,
+					Distance:  point.DistanceMeters,
+					HeartRate: point.HeartRate,
+					Cadence:   firstPositive(point.Extensions.RunCadence, point.Cadence),
+					Power:     point.Extensions.Watts,
+				}
+				if point.Position != nil {
+					sample.HasPosition = true
+					sample.Latitude, sample.Longitude = point.Position.Latitude, point.Position.Longitude
+				}
+				if point.Altitude != nil {
+					sample.HasElevation, sample.Elevation = true, *point.Altitude
+				}
+				track.Samples = append(track.Samples, sample)
+			}
+		}
+	}
+	return finish(track)
+}
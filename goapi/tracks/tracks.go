@@ -0,0 +1,268 @@
+// Package tracks reads activities recorded by watches and phones, and summarizes them the way the activity
+// table stores them: distance, moving time, elevation gain and heart rate statistics, with the laps and the
+// samples of the recording.
+package tracks
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"goapi/fit"
+	"goapi/models"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	earthRadiusMeters = 6371000.0
+
+	// Intervals slower than this, in meters per second, are standing still and do not count as moving time.
+	minMovingSpeed = 0.5
+	// Longer pauses between samples than this, in seconds, are the recording being paused.
+	maxSampleGap = 60.0
+	// Elevation changes smaller than this, in meters, are treated as noise from the altimeter or GPS.
+	elevationThreshold = 3.0
+)
+
+// ErrUnknownFormat is returned for files that are not GPX, TCX or FIT files.
+var ErrUnknownFormat = errors.New("the file is not a GPX, TCX or FIT file")
+
+// ErrNoSamples is returned for files without any timed samples.
+var ErrNoSamples = errors.New("the file has no samples with a time")
+
+// ErrNoDuration is returned for files whose samples were all recorded at the same second, like a single point.
+var ErrNoDuration = errors.New("the file covers less than a second")
+
+// Track is a recorded activity, with its samples in the order they were recorded.
+type Track struct {
+	Source  string
+	Samples []models.Sample
+	Laps    []models.Lap
+	// Summary holds the totals recorded by the device, for the formats that have them. They take precedence
+	// over the totals computed from the samples.
+	Summary *models.Activity
+}
+
+// Parse reads a GPX 1.1, Garmin TCX or FIT file. FIT files are recognized by their header, and the XML formats
+// by the root element.
+func Parse(data []byte) (Track, error) {
+	if fit.IsFIT(data) {
+		return ParseFIT(data)
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return Track{}, ErrUnknownFormat
+	}
+
+	switch root {
+	case "gpx":
+		return ParseGPX(data)
+	case "TrainingCenterDatabase":
+		return ParseTCX(data)
+	default:
+		return Track{}, ErrUnknownFormat
+	}
+}
+
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// finish sorts the samples by time, drops the samples without a time, fills in the distance covered from the
+// positions when the file did not record it, and adds the statistics of the laps. Activities must last, so
+// tracks without a duration are rejected.
+func finish(track Track) (Track, error) {
+	samples := track.Samples[:0]
+	for _, sample := range track.Samples {
+		if !sample.Time.IsZero() {
+			samples = append(samples, sample)
+		}
+	}
+	if len(samples) == 0 {
+		return Track{}, ErrNoSamples
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+	track.Samples = samples
+
+	if hasRecordedDistance(samples) {
+		// Some samples, like the ones recorded while the watch searches for GPS, lack the distance.
+		for i := 1; i < len(samples); i++ {
+			if samples[i].Distance < samples[i-1].Distance {
+				samples[i].Distance = samples[i-1].Distance
+			}
+		}
+	} else {
+		distance := 0.0
+		var previous *models.Sample
+		for i := range samples {
+			if !samples[i].HasPosition {
+				samples[i].Distance = distance
+				continue
+			}
+			// The jump from the end of a segment to the start of the next one is not covered.
+			if previous != nil && previous.Segment == samples[i].Segment {
+				distance += haversine(*previous, samples[i])
+			}
+			samples[i].Distance = distance
+			previous = &samples[i]
+		}
+	}
+
+	for i := range track.Laps {
+		track.Laps[i].Order = i
+		end := time.Time{}
+		if i+1 < len(track.Laps) {
+			end = track.Laps[i+1].StartTime
+		}
+		lapSamples := samplesBetween(samples, track.Laps[i].StartTime, end)
+		track.Laps[i].AverageHeartRate, track.Laps[i].MaxHeartRate = heartRate(lapSamples)
+		if track.Laps[i].Distance == 0 && len(lapSamples) > 0 {
+			track.Laps[i].Distance = lapSamples[len(lapSamples)-1].Distance - lapSamples[0].Distance
+		}
+	}
+	if track.Activity().Duration <= 0 {
+		return Track{}, ErrNoDuration
+	}
+	return track, nil
+}
+
+func hasRecordedDistance(samples []models.Sample) bool {
+	for _, sample := range samples {
+		if sample.Distance > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// samplesBetween returns the samples from start up to end. A zero end includes the rest of the samples.
+func samplesBetween(samples []models.Sample, start, end time.Time) []models.Sample {
+	first := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Time.Before(start)
+	})
+	last := len(samples)
+	if !end.IsZero() {
+		last = sort.Search(len(samples), func(i int) bool {
+			return !samples[i].Time.Before(end)
+		})
+	}
+	if first > last {
+		return nil
+	}
+	return samples[first:last]
+}
+
+// Activity summarizes the track as an activity.
+func (t Track) Activity() models.Activity {
+	samples := t.Samples
+	first, last := samples[0], samples[len(samples)-1]
+
+	activity := models.Activity{
+		StartTime:     first.Time,
+		Duration:      int(math.Round(last.Time.Sub(first.Time).Seconds())),
+		MovingTime:    int(math.Round(movingTime(samples))),
+		Distance:      int(math.Round(last.Distance - first.Distance)),
+		ElevationGain: int(math.Round(elevationGain(samples))),
+		Source:        t.Source,
+	}
+	activity.AverageHeartRate, activity.MaxHeartRate = heartRate(samples)
+
+	if summary := t.Summary; summary != nil {
+		for _, value := range []struct{ summary, activity *int }{
+			{&summary.Duration, &activity.Duration},
+			{&summary.MovingTime, &activity.MovingTime},
+			{&summary.Distance, &activity.Distance},
+			{&summary.ElevationGain, &activity.ElevationGain},
+			{&summary.AverageHeartRate, &activity.AverageHeartRate},
+			{&summary.MaxHeartRate, &activity.MaxHeartRate},
+		} {
+			if *value.summary > 0 {
+				*value.activity = *value.summary
+			}
+		}
+		if !summary.StartTime.IsZero() {
+			activity.StartTime = summary.StartTime
+		}
+	}
+	return activity
+}
+
+// movingTime sums up the intervals between samples where the athlete was moving. Recordings without any
+// distance, like treadmill runs without a foot pod, count as moving except for the pauses.
+func movingTime(samples []models.Sample) float64 {
+	hasDistance := samples[len(samples)-1].Distance > 0
+
+	moving := 0.0
+	for i := 1; i < len(samples); i++ {
+		seconds := samples[i].Time.Sub(samples[i-1].Time).Seconds()
+		if seconds <= 0 || seconds > maxSampleGap {
+			continue
+		}
+		meters := samples[i].Distance - samples[i-1].Distance
+		if !hasDistance || meters/seconds >= minMovingSpeed {
+			moving += seconds
+		}
+	}
+	return moving
+}
+
+// elevationGain sums up the climbs, ignoring changes smaller than the elevation threshold.
+func elevationGain(samples []models.Sample) float64 {
+	gain := 0.0
+	reference, found := 0.0, false
+	for _, sample := range samples {
+		if !sample.HasElevation {
+			continue
+		}
+		switch {
+		case !found:
+			reference, found = sample.Elevation, true
+		case sample.Elevation >= reference+elevationThreshold:
+			gain += sample.Elevation - reference
+			reference = sample.Elevation
+		case sample.Elevation <= reference-elevationThreshold:
+			reference = sample.Elevation
+		}
+	}
+	return gain
+}
+
+func heartRate(samples []models.Sample) (int, int) {
+	sum, count, max := 0, 0, 0
+	for _, sample := range samples {
+		if sample.HeartRate <= 0 {
+			continue
+		}
+		sum += sample.HeartRate
+		count++
+		if sample.HeartRate > max {
+			max = sample.HeartRate
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return int(math.Round(float64(sum) / float64(count))), max
+}
+
+func haversine(a, b models.Sample) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	deltaLat := lat2 - lat1
+	deltaLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
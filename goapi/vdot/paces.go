@@ -0,0 +1,69 @@
+package vdot
+
+const (
+	marathonMeters = 42195.0
+
+	// The fractions of VDOT the training intensities are run at.
+	easyFastestFraction = 0.70
+	easySlowestFraction = 0.62
+	thresholdFraction   = 0.88
+	intervalFraction    = 0.975
+
+	// Repetitions are run about 6 seconds per 400 meters faster than intervals.
+	repetitionSecondsPerKmFasterThanInterval = 15.0
+)
+
+// Pace is a pace range in seconds per kilometer. Constant paces have the same fastest and slowest pace.
+type Pace struct {
+	Fastest float64
+	Slowest float64
+}
+
+// Average is the pace in the middle of the range.
+func (p Pace) Average() float64 {
+	return (p.Fastest + p.Slowest) / 2
+}
+
+// Paces are the training paces of a VDOT.
+type Paces struct {
+	Easy       Pace
+	Marathon   Pace
+	Threshold  Pace
+	Interval   Pace
+	Repetition Pace
+}
+
+// TrainingPaces derives the Easy, Marathon, Threshold, Interval and Repetition paces of a VDOT.
+func TrainingPaces(vdot float64) Paces {
+	if vdot <= 0 {
+		return Paces{}
+	}
+
+	marathon := RaceTime(vdot, marathonMeters) / (marathonMeters / 1000)
+	interval := paceAt(vdot, intervalFraction)
+
+	return Paces{
+		Easy:       Pace{Fastest: paceAt(vdot, easyFastestFraction), Slowest: paceAt(vdot, easySlowestFraction)},
+		Marathon:   constant(marathon),
+		Threshold:  constant(paceAt(vdot, thresholdFraction)),
+		Interval:   constant(interval),
+		Repetition: constant(interval - repetitionSecondsPerKmFasterThanInterval),
+	}
+}
+
+// paceAt is the pace, in seconds per kilometer, where the oxygen cost is the given fraction of the VDOT.
+func paceAt(vdot, fraction float64) float64 {
+	return 60 * 1000 / VelocityAt(vdot, fraction)
+}
+
+func constant(secondsPerKm float64) Pace {
+	return Pace{Fastest: secondsPerKm, Slowest: secondsPerKm}
+}
+
+// PaceBand is the pace range where the oxygen cost is between the given fractions of the VDOT.
+func PaceBand(vdot, lowFraction, highFraction float64) Pace {
+	if vdot <= 0 || lowFraction <= 0 || highFraction <= 0 {
+		return Pace{}
+	}
+	return Pace{Fastest: paceAt(vdot, highFraction), Slowest: paceAt(vdot, lowFraction)}
+}
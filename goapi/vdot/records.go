@@ -0,0 +1,50 @@
+package vdot
+
+import (
+	"goapi/models"
+	"time"
+)
+
+// RecentMonths is how far back records count towards the current VDOT of a profile.
+const RecentMonths = 12
+
+// OfRecord calculates the VDOT of a record, or 0 if the distance or duration of the record is unknown.
+func OfRecord(record models.Record) float64 {
+	return FromRace(float64(record.Distance), float64(record.Duration))
+}
+
+// Best finds the highest VDOT among the records set on or after since. Records without a date are ignored.
+// The second return value is false when there are no such records.
+func Best(records []models.Record, since time.Time) (float64, bool) {
+	record, found := BestRecord(records, since)
+	if !found {
+		return 0, false
+	}
+	return OfRecord(record), true
+}
+
+// BestRecord finds the record with the highest VDOT among the records set on or after since.
+// A zero since includes records without a date.
+func BestRecord(records []models.Record, since time.Time) (models.Record, bool) {
+	var best models.Record
+	bestValue, found := 0.0, false
+	for _, record := range records {
+		if !since.IsZero() && (record.Date.IsZero() || record.Date.Before(since)) {
+			continue
+		}
+		if value := OfRecord(record); value > bestValue {
+			best, bestValue, found = record, value, true
+		}
+	}
+	return best, found
+}
+
+// Current is the best VDOT of the records set on or after since. Profiles without such records keep the VDOT
+// set on the profile.
+func Current(profile models.Profile, records []models.Record, since time.Time) float64 {
+	best, found := Best(records, since)
+	if !found {
+		return float64(profile.Vdot)
+	}
+	return best
+}
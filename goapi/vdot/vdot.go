@@ -0,0 +1,56 @@
+// Package vdot implements Jack Daniels' and Jimmy Gilbert's VDOT model, which rates a runner by the
+// oxygen uptake their race results imply, and derives race predictions and training paces from it.
+package vdot
+
+import "math"
+
+const (
+	// The shortest and longest race durations, in seconds, that race times are searched between.
+	minRaceSeconds = 60.0
+	maxRaceSeconds = 24 * 60 * 60.0
+)
+
+// OxygenCost is the oxygen uptake, in ml/kg/min, needed to run at the given velocity in meters per minute.
+func OxygenCost(velocity float64) float64 {
+	return -4.60 + 0.182258*velocity + 0.000104*velocity*velocity
+}
+
+// FractionOfMax is the fraction of VO2max a runner can sustain for a race lasting the given number of
+// minutes, also known as the drop dead formula.
+func FractionOfMax(minutes float64) float64 {
+	return 0.8 + 0.1894393*math.Exp(-0.012778*minutes) + 0.2989558*math.Exp(-0.1932605*minutes)
+}
+
+// FromRace calculates the VDOT of a race result.
+func FromRace(meters, seconds float64) float64 {
+	if meters <= 0 || seconds <= 0 {
+		return 0
+	}
+	minutes := seconds / 60
+	return OxygenCost(meters/minutes) / FractionOfMax(minutes)
+}
+
+// VelocityAt is the velocity, in meters per minute, where the oxygen cost is the given fraction of the VDOT.
+func VelocityAt(vdot, fraction float64) float64 {
+	a, b, c := 0.000104, 0.182258, -4.60-fraction*vdot
+	return (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+}
+
+// RaceTime predicts the time, in seconds, a runner with the given VDOT needs to run the distance.
+func RaceTime(vdot, meters float64) float64 {
+	if vdot <= 0 || meters <= 0 {
+		return 0
+	}
+
+	// The VDOT of a race decreases as the time increases, so the time is found by bisection.
+	low, high := minRaceSeconds, maxRaceSeconds
+	for i := 0; i < 100 && high-low > 0.01; i++ {
+		middle := (low + high) / 2
+		if FromRace(meters, middle) > vdot {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}